@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/cyrilix/robocar-steering/pkg/steering"
+	"go.uber.org/zap"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	var recordsDir, objectsDir, output, format, source string
+	var gridMapConfig, objectsMoveFactorsConfig, objectsProfilesConfig, gridInterpolation, objectsStrategy string
+	var pipelineConfig, calibrationConfig, distanceSource string
+	var deltaMiddle, objectsMinConfidence float64
+	var objectsConfidenceWeighting, enableObjectsCorrectionOnUserMode bool
+
+	flag.StringVar(&recordsDir, "records-dir", "", "Directory of recorded protobuf RecordMessage files to replay")
+	flag.StringVar(&objectsDir, "objects-dir", "", "Directory of recorded protobuf ObjectsMessage files, optional")
+	flag.StringVar(&output, "output", "", "File to write corrected steering, stdout if not set")
+	flag.StringVar(&format, "format", "csv", "Output format (csv|json)")
+	flag.StringVar(&source, "source", string(steering.ReplayAuto), "Steering to replay, auto uses radio command steering on user drive mode and autopilot steering else (auto|rc|tf)")
+	flag.BoolVar(&enableObjectsCorrectionOnUserMode, "enable-objects-correction-user", false, "Adjust radio command steering to avoid objects")
+	flag.Float64Var(&objectsMinConfidence, "objects-min-confidence", 0., "Minimal confidence of objects to avoid")
+	flag.BoolVar(&objectsConfidenceWeighting, "objects-confidence-weighting", false, "Scale objects correction by detection confidence")
+	flag.StringVar(&gridMapConfig, "grid-map-config", "", "Json file path to configure grid object correction")
+	flag.StringVar(&objectsMoveFactorsConfig, "objects-move-factors-config", "", "Json file path to configure objects move corrections")
+	flag.StringVar(&objectsProfilesConfig, "objects-profiles-config", "", "Json file path to configure correction by type of object (CAR, BUMP, PLOT, ANY)")
+	flag.StringVar(&gridInterpolation, "grid-interpolation", "", "Interpolation mode to apply on grid maps (nearest|bilinear|bicubic), use json config value if not set")
+	flag.StringVar(&objectsStrategy, "objects-strategy", string(steering.StrategyMaxMagnitude), "Strategy to combine correction of several objects (max|weighted|gap)")
+	flag.Float64Var(&deltaMiddle, "delta-middle", 0.1, "Half Percent zone to interpret as straight")
+	flag.StringVar(&distanceSource, "distance-source", "depth", "Distance to use for grid map rows: object depth in mm when available with fallback to bottom position, or only bottom position (depth|bottom)")
+	flag.StringVar(&pipelineConfig, "pipeline-config", "", "Json file path to declare steering processors pipeline, objects correction only if not set, enable-objects-correction-user is rejected if set. Calibration is applied after declared pipeline unless declared by it")
+	flag.StringVar(&calibrationConfig, "calibration-config", "", "Json file path to configure steering calibration of the car")
+	logLevel := zap.LevelFlag("log", zap.InfoLevel, "log level")
+	flag.Parse()
+
+	if len(os.Args) <= 1 {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(*logLevel)
+	lgr, err := config.Build()
+	if err != nil {
+		log.Fatalf("unable to init logger: %v", err)
+	}
+	defer func() {
+		if err := lgr.Sync(); err != nil {
+			log.Printf("unable to Sync logger: %v\n", err)
+		}
+	}()
+	zap.ReplaceGlobals(lgr)
+
+	zap.S().Infof("records directory               : %v", recordsDir)
+	zap.S().Infof("objects directory               : %v", objectsDir)
+	zap.S().Infof("replayed steering               : %v", source)
+	zap.S().Infof("grid map file config            : %v", gridMapConfig)
+	zap.S().Infof("objects move factors grid config: %v", objectsMoveFactorsConfig)
+	zap.S().Infof("pipeline config                 : %v", pipelineConfig)
+	zap.S().Infof("calibration config              : %v", calibrationConfig)
+
+	if recordsDir == "" {
+		zap.S().Fatalf("records directory is mandatory")
+	}
+	switch steering.ReplaySource(source) {
+	case steering.ReplayAuto, steering.ReplayRC, steering.ReplayTF:
+	default:
+		zap.S().Fatalf("invalid source '%v', must be 'auto', 'rc' or 'tf'", source)
+	}
+	var write func(io.Writer, []*steering.ReplayResult) error
+	switch format {
+	case "csv":
+		write = writeCSV
+	case "json":
+		write = writeJSON
+	default:
+		zap.S().Fatalf("invalid format '%v', must be 'csv' or 'json'", format)
+	}
+
+	gridMapOption, err := steering.WithGridMap(gridMapConfig)
+	if err != nil {
+		zap.S().Fatalf("unable to configure grid map: %v", err)
+	}
+	objectMoveFactorsOption, err := steering.WithObjectMoveFactors(objectsMoveFactorsConfig)
+	if err != nil {
+		zap.S().Fatalf("unable to configure objects move factors: %v", err)
+	}
+	profilesOption, err := steering.WithTypeProfiles(objectsProfilesConfig)
+	if err != nil {
+		zap.S().Fatalf("unable to configure objects profiles: %v", err)
+	}
+	var ds steering.DistanceSource
+	switch distanceSource {
+	case "depth":
+		ds = steering.DepthDistance{}
+	case "bottom":
+		ds = steering.BottomDistance{}
+	default:
+		zap.S().Fatalf("invalid distance source '%v', must be 'depth' or 'bottom'", distanceSource)
+	}
+	interpolation := steering.Interpolation(gridInterpolation)
+	switch interpolation {
+	case "", steering.InterpolationNearest, steering.InterpolationBilinear, steering.InterpolationBicubic:
+	default:
+		zap.S().Fatalf("invalid grid interpolation '%v', must be 'nearest', 'bilinear' or 'bicubic'", gridInterpolation)
+	}
+	strategy := steering.ObjectsStrategy(objectsStrategy)
+	switch strategy {
+	case steering.StrategyMaxMagnitude, steering.StrategyWeightedSum, steering.StrategyFreeGap:
+	default:
+		zap.S().Fatalf("invalid objects strategy '%v', must be 'max', 'weighted' or 'gap'", objectsStrategy)
+	}
+	corrector := steering.NewGridCorrector(
+		steering.WithDistanceSource(ds),
+		steering.WidthDeltaMiddle(deltaMiddle),
+		gridMapOption,
+		objectMoveFactorsOption,
+		profilesOption,
+		steering.WithInterpolation(interpolation),
+		steering.WithObjectsStrategy(strategy),
+	)
+
+	processors := []steering.Processor{steering.NewCorrectorProcessor(corrector, enableObjectsCorrectionOnUserMode)}
+	if pipelineConfig != "" {
+		userModeSet := false
+		flag.Visit(func(f *flag.Flag) {
+			userModeSet = userModeSet || f.Name == "enable-objects-correction-user"
+		})
+		if userModeSet {
+			zap.S().Fatalf("flag -enable-objects-correction-user configures objects correction declared by pipeline config, use user_mode in '%v'", pipelineConfig)
+		}
+		processors, err = steering.LoadProcessors(pipelineConfig, corrector)
+		if err != nil {
+			zap.S().Fatalf("unable to load pipeline config: %v", err)
+		}
+		for _, p := range processors {
+			if _, ok := p.(*steering.Calibration); ok && calibrationConfig != "" {
+				zap.S().Fatalf("flag -calibration-config configures calibration declared by pipeline config '%v'", pipelineConfig)
+			}
+		}
+	}
+	if calibrationConfig != "" {
+		calibration, err := steering.LoadCalibration(calibrationConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to load calibration config: %v", err)
+		}
+		processors = append(processors, calibration)
+	}
+
+	records, err := steering.LoadRecords(recordsDir, objectsDir)
+	if err != nil {
+		zap.S().Fatalf("unable to load records: %v", err)
+	}
+	zap.S().Infof("%d record(s) loaded", len(records))
+
+	start := time.Now()
+	filter := steering.NewConfidenceFilter(objectsMinConfidence, objectsConfidenceWeighting)
+	results := steering.Replay(records, steering.ReplaySource(source), processors, filter)
+	zap.S().Infof("%d steering value(s) replayed in %v", len(results), time.Since(start))
+
+	w := os.Stdout
+	if output != "" {
+		w, err = os.Create(output)
+		if err != nil {
+			zap.S().Fatalf("unable to create output file: %v", err)
+		}
+		defer func() {
+			if err := w.Close(); err != nil {
+				zap.S().Errorf("unable to close output file: %v", err)
+			}
+		}()
+	}
+	if err := write(w, results); err != nil {
+		zap.S().Fatalf("unable to write results: %v", err)
+	}
+}
+
+func writeCSV(w io.Writer, results []*steering.ReplayResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"frame_id", "timestamp", "drive_mode", "source", "objects", "original", "corrected"}); err != nil {
+		return fmt.Errorf("unable to write csv header: %w", err)
+	}
+	for _, r := range results {
+		err := cw.Write([]string{
+			r.FrameId,
+			r.Timestamp.Format(time.RFC3339Nano),
+			r.DriveMode,
+			string(r.Source),
+			strconv.Itoa(r.Objects),
+			strconv.FormatFloat(r.Original, 'f', -1, 64),
+			strconv.FormatFloat(r.Corrected, 'f', -1, 64),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to write csv line of frame %v: %w", r.FrameId, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, results []*steering.ReplayResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/cyrilix/robocar-steering/pkg/steering"
+	"go.uber.org/zap"
+	"log"
+	"os"
+)
+
+func main() {
+	var recordsDir, objectsDir string
+	var gridMapConfig, objectsMoveFactorsConfig, gridMapOutput, objectsMoveFactorsOutput string
+	var regularization, deltaMiddle, factorStep, objectsMinConfidence float64
+
+	flag.StringVar(&recordsDir, "records-dir", "", "Directory of recorded protobuf RecordMessage files of user drive mode sessions")
+	flag.StringVar(&objectsDir, "objects-dir", "", "Directory of recorded protobuf ObjectsMessage files")
+	flag.StringVar(&gridMapConfig, "grid-map-config", "", "Json file path of initial grid map, default grid map if not set")
+	flag.StringVar(&objectsMoveFactorsConfig, "objects-move-factors-config", "", "Json file path of initial objects move factors, default factors if not set")
+	flag.StringVar(&gridMapOutput, "grid-map-output", "grid-map.json", "Json file path to write fitted grid map")
+	flag.StringVar(&objectsMoveFactorsOutput, "objects-move-factors-output", "objects-move-factors.json", "Json file path to write fitted objects move factors")
+	flag.Float64Var(&regularization, "regularization", 1., "Weight of initial value of each cell, as a count of samples, 0 for plain least squares")
+	flag.Float64Var(&deltaMiddle, "delta-middle", 0.1, "Half Percent zone of driver steering to interpret as straight")
+	flag.Float64Var(&factorStep, "factor-step", 0.05, "Step of values tried for each objects move factor")
+	flag.Float64Var(&objectsMinConfidence, "objects-min-confidence", 0., "Minimal confidence of objects to use")
+	logLevel := zap.LevelFlag("log", zap.InfoLevel, "log level")
+	flag.Parse()
+
+	if len(os.Args) <= 1 {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(*logLevel)
+	lgr, err := config.Build()
+	if err != nil {
+		log.Fatalf("unable to init logger: %v", err)
+	}
+	defer func() {
+		if err := lgr.Sync(); err != nil {
+			log.Printf("unable to Sync logger: %v\n", err)
+		}
+	}()
+	zap.ReplaceGlobals(lgr)
+
+	zap.S().Infof("records directory               : %v", recordsDir)
+	zap.S().Infof("objects directory               : %v", objectsDir)
+	zap.S().Infof("grid map file config            : %v", gridMapConfig)
+	zap.S().Infof("objects move factors grid config: %v", objectsMoveFactorsConfig)
+	zap.S().Infof("regularization                  : %v", regularization)
+
+	if recordsDir == "" || objectsDir == "" {
+		zap.S().Fatalf("records and objects directories are mandatory")
+	}
+	if factorStep <= 0. {
+		zap.S().Fatalf("invalid factor step %v, must be positive", factorStep)
+	}
+
+	var gridMap, objectsMoveFactors *steering.GridMap
+	if gridMapConfig != "" {
+		gridMap, err = steering.NewGridMapFromJson(gridMapConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to load grid map: %v", err)
+		}
+	}
+	if objectsMoveFactorsConfig != "" {
+		objectsMoveFactors, err = steering.NewGridMapFromJson(objectsMoveFactorsConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to load objects move factors: %v", err)
+		}
+	}
+
+	for _, gm := range []*steering.GridMap{gridMap, objectsMoveFactors} {
+		if gm != nil && gm.Interpolation != "" && gm.Interpolation != steering.InterpolationNearest {
+			zap.S().Warnf("grid maps are fitted and written with nearest interpolation, %v interpolation is ignored", gm.Interpolation)
+		}
+	}
+
+	tuner, err := steering.NewTuner(gridMap, objectsMoveFactors,
+		steering.WithTuneRegularization(regularization),
+		steering.WithTuneDeltaMiddle(deltaMiddle),
+		steering.WithTuneFactorStep(factorStep),
+	)
+	if err != nil {
+		zap.S().Fatalf("unable to configure tuner: %v", err)
+	}
+
+	records, err := steering.LoadRecords(recordsDir, objectsDir)
+	if err != nil {
+		zap.S().Fatalf("unable to load records: %v", err)
+	}
+	filter := steering.NewConfidenceFilter(objectsMinConfidence, false)
+	for _, r := range records {
+		if r.Objects != nil {
+			r.Objects.Objects = filter.Filter(r.Objects.GetObjects())
+		}
+	}
+	samples := steering.NewTuneSamples(records)
+	zap.S().Infof("%d record(s) loaded, %d sample(s) with objects", len(records), len(samples))
+	if len(samples) == 0 {
+		zap.S().Fatalf("no user drive mode record with objects, nothing to fit")
+	}
+
+	report := tuner.Fit(samples)
+
+	if err := writeGridMap(gridMapOutput, tuner.GridMap()); err != nil {
+		zap.S().Fatalf("unable to write grid map: %v", err)
+	}
+	if err := writeGridMap(objectsMoveFactorsOutput, tuner.ObjectMoveFactors()); err != nil {
+		zap.S().Fatalf("unable to write objects move factors: %v", err)
+	}
+	if _, err := report.WriteTo(os.Stdout); err != nil {
+		zap.S().Fatalf("unable to write report: %v", err)
+	}
+}
+
+// writeGridMap writes grid map with json format loaded by steering.WithGridMap
+func writeGridMap(path string, gm *steering.GridMap) error {
+	if err := gm.Validate(); err != nil {
+		return fmt.Errorf("invalid fitted grid map: %w", err)
+	}
+	content, err := json.MarshalIndent(gm, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal grid map: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("unable to write '%v': %w", path, err)
+	}
+	return nil
+}
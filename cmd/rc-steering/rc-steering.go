@@ -3,24 +3,70 @@ package main
 import (
 	"flag"
 	"github.com/cyrilix/robocar-base/cli"
+	"github.com/cyrilix/robocar-protobuf/go/events"
 	"github.com/cyrilix/robocar-steering/pkg/steering"
 	"go.uber.org/zap"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
 	DefaultClientId = "robocar-steering"
 )
 
+// pipelineFlags configure stages of default pipeline, they can't be used with pipeline config that declares these stages
+var pipelineFlags = []string{
+	"enable-objects-correction", "enable-objects-correction-user",
+	"enable-road-correction", "road-image-width", "road-weight", "road-offset-gain", "road-heading-gain",
+	"road-min-confidence", "road-fallback-confidence", "road-ttl",
+	"confidence-threshold", "confidence-fallback", "confidence-blend", "last-good-decay",
+	"steering-max-rate", "steering-ema-alpha", "enable-steering-one-euro", "enable-steering-filters-user",
+	"one-euro-min-cutoff", "one-euro-beta", "one-euro-d-cutoff",
+}
+
 func main() {
 	var mqttBroker, username, password, clientId string
 	var steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, objectsTopic string
+	var throttleTopic, speedZoneTopic, roadTopic string
+	var tfSteeringEnsembleTopics, ensembleFusion string
+	var ensembleWindow time.Duration
+	var enableRoadCorrection bool
+	var roadImageWidth int
+	var roadWeight, roadOffsetGain, roadHeadingGain, roadMinConfidence, roadFallbackConfidence float64
+	var roadTTL time.Duration
+	var confidenceThreshold float64
+	var confidenceFallback string
+	var confidenceBlend bool
+	var lastGoodDecay time.Duration
+	var copilotAuthority, copilotOverrideThreshold float64
+	var copilotRecovery, copilotModelTTL time.Duration
+	var failsafeTopic, failsafeSteering string
+	var steeringDebugTopic string
+	var watchdogTimeout, failsafeDecay time.Duration
+	var transitionDuration time.Duration
+	var metricsAddr string
+	var gridMapSlowConfig, gridMapNormalConfig, gridMapFastConfig string
+	var throttleGainAtStop, throttleGainAtFull float64
 	var enableObjectsCorrection, enableObjectsCorrectionOnUserMode bool
-	var gridMapConfig, objectsMoveFactorsConfig string
+	var gridMapConfig, objectsMoveFactorsConfig, gridInterpolation, objectsStrategy, objectsProfilesConfig, pipelineConfig, calibrationConfig string
 	var deltaMiddle float64
+	var correctorType, distanceSource string
+	var objectsTTL, frameSyncTolerance time.Duration
+	var enableFrameSync, enableTracking bool
+	var frameSyncBufferSize, trackerMinHits, trackerMaxMisses int
+	var trackerIoUThreshold, trackerSmoothing, ttcMaxGain float64
+	var ttcHorizon time.Duration
+	var steeringMaxRate, steeringEMAAlpha, oneEuroMinCutoff, oneEuroBeta, oneEuroDCutoff float64
+	var enableOneEuro, enableSteeringFiltersOnUserMode bool
+	var gapLookahead, gapSafetyMargin, gapMinCorridorWidth float64
 
 	mqttQos := cli.InitIntFlag("MQTT_QOS", 0)
+	objectsMinConfidence := cli.InitFloat64Flag("OBJECTS_MIN_CONFIDENCE", 0.)
+	_, objectsConfidenceWeighting := os.LookupEnv("OBJECTS_CONFIDENCE_WEIGHTING")
 	_, mqttRetain := os.LookupEnv("MQTT_RETAIN")
 
 	cli.InitMqttFlags(DefaultClientId, &mqttBroker, &username, &password, &clientId, &mqttQos, &mqttRetain)
@@ -28,13 +74,77 @@ func main() {
 	flag.StringVar(&steeringTopic, "mqtt-topic-steering", os.Getenv("MQTT_TOPIC_STEERING"), "Mqtt topic to publish steering result, use MQTT_TOPIC_STEERING if args not set")
 	flag.StringVar(&rcSteeringTopic, "mqtt-topic-rc-steering", os.Getenv("MQTT_TOPIC_RC_STEERING"), "Mqtt topic that contains RC steering value, use MQTT_TOPIC_RC_STEERING if args not set")
 	flag.StringVar(&tfSteeringTopic, "mqtt-topic-tf-steering", os.Getenv("MQTT_TOPIC_TF_STEERING"), "Mqtt topic that contains tenorflow steering value, use MQTT_TOPIC_TF_STEERING if args not set")
+	flag.StringVar(&tfSteeringEnsembleTopics, "mqtt-topic-tf-steering-ensemble", os.Getenv("MQTT_TOPIC_TF_STEERING_ENSEMBLE"), "Comma separated list of mqtt topics that contain steering of several tensorflow models to fuse, replace mqtt-topic-tf-steering if set, use MQTT_TOPIC_TF_STEERING_ENSEMBLE if args not set")
+	flag.StringVar(&ensembleFusion, "ensemble-fusion", string(steering.FusionWeighted), "Strategy to fuse steering of several tensorflow models (weighted|median|most-confident)")
+	flag.DurationVar(&ensembleWindow, "ensemble-window", 50*time.Millisecond, "Max delay to wait steering of all tensorflow models for a frame")
 	flag.StringVar(&driveModeTopic, "mqtt-topic-drive-mode", os.Getenv("MQTT_TOPIC_DRIVE_MODE"), "Mqtt topic that contains DriveMode value, use MQTT_TOPIC_DRIVE_MODE if args not set")
 	flag.StringVar(&objectsTopic, "mqtt-topic-objects", os.Getenv("MQTT_TOPIC_OBJECTS"), "Mqtt topic that contains Objects from object detection value, use MQTT_TOPIC_OBJECTS if args not set")
+	flag.StringVar(&throttleTopic, "mqtt-topic-throttle", os.Getenv("MQTT_TOPIC_THROTTLE"), "Optional mqtt topic that contains throttle value to adapt objects correction to speed, use MQTT_TOPIC_THROTTLE if args not set")
+	flag.StringVar(&speedZoneTopic, "mqtt-topic-speed-zone", os.Getenv("MQTT_TOPIC_SPEED_ZONE"), "Optional mqtt topic that contains speed zone to adapt objects correction to speed, use MQTT_TOPIC_SPEED_ZONE if args not set")
+	flag.StringVar(&roadTopic, "mqtt-topic-road", os.Getenv("MQTT_TOPIC_ROAD"), "Mqtt topic that contains road detection, use MQTT_TOPIC_ROAD if args not set")
+	flag.BoolVar(&enableRoadCorrection, "enable-road-correction", false, "Blend lane-keeping correction from road into tflite steering, needs road topic")
+	flag.IntVar(&roadImageWidth, "road-image-width", 160, "Width in pixels of images used for road detection")
+	flag.Float64Var(&roadWeight, "road-weight", 0.3, "Weight of lane-keeping steering blended into tflite steering, between 0 and 1")
+	flag.Float64Var(&roadOffsetGain, "road-offset-gain", 1., "Factor to apply on lateral offset of road to compute lane-keeping steering")
+	flag.Float64Var(&roadHeadingGain, "road-heading-gain", 1., "Factor to apply on heading error of road to compute lane-keeping steering")
+	flag.Float64Var(&roadMinConfidence, "road-min-confidence", 0., "Minimal confidence of road ellipse to use it")
+	flag.Float64Var(&roadFallbackConfidence, "road-fallback-confidence", 0., "Use lane-keeping steering in place of tflite steering when model confidence is below this value, 0 to disable")
+	flag.DurationVar(&roadTTL, "road-ttl", 500*time.Millisecond, "Delay after which road is ignored if no new road is received")
+	flag.Float64Var(&confidenceThreshold, "confidence-threshold", 0., "Model confidence below which tflite steering is replaced by fallback, 0 to disable")
+	flag.StringVar(&confidenceFallback, "confidence-fallback", string(steering.FallbackLastGood), "Steering to use when model confidence is below threshold (road|last-good|straight)")
+	flag.BoolVar(&confidenceBlend, "confidence-blend", false, "Blend model and fallback steering proportionally to model confidence instead of switching")
+	flag.DurationVar(&lastGoodDecay, "last-good-decay", time.Second, "Time constant of decay to straight of last good steering, for last-good fallback")
+	flag.Float64Var(&copilotAuthority, "copilot-authority", 0.5, "Weight of tflite steering blended into radio command steering on copilot drive mode, between 0 and 1")
+	flag.Float64Var(&copilotOverrideThreshold, "copilot-override-threshold", 0.5, "Radio command steering magnitude above which human overrides tflite steering on copilot drive mode")
+	flag.DurationVar(&copilotRecovery, "copilot-authority-recovery", time.Second, "Delay to hand back authority to tflite steering after a human override on copilot drive mode")
+	flag.DurationVar(&copilotModelTTL, "copilot-model-ttl", 500*time.Millisecond, "Delay after which tflite steering is ignored on copilot drive mode if no new value is received")
+	flag.StringVar(&steeringDebugTopic, "mqtt-topic-steering-debug", os.Getenv("MQTT_TOPIC_STEERING_DEBUG"), "Mqtt topic to publish JSON diagnostics of each steering output, disabled if empty, use MQTT_TOPIC_STEERING_DEBUG if args not set")
+	flag.StringVar(&failsafeTopic, "mqtt-topic-failsafe", os.Getenv("MQTT_TOPIC_FAILSAFE"), "Mqtt topic to publish DriveMode alert when steering input is missing, use MQTT_TOPIC_FAILSAFE if args not set")
+	flag.DurationVar(&watchdogTimeout, "watchdog-timeout", 0, "Delay without steering input on active source before to publish safe steering, 0 to disable")
+	flag.StringVar(&failsafeSteering, "failsafe-steering", string(steering.SafeCentre), "Steering to publish when input is missing (centre|decay)")
+	flag.DurationVar(&failsafeDecay, "failsafe-decay", 500*time.Millisecond, "Duration to move last steering to centre, for decay failsafe steering")
+	flag.StringVar(&metricsAddr, "metrics-addr", os.Getenv("METRICS_ADDR"), "Address to expose Prometheus metrics on /metrics (ex: ':9100'), disabled if empty, use METRICS_ADDR env if args not set")
+	flag.DurationVar(&transitionDuration, "drive-mode-transition", 0, "Duration to ramp steering from last published value to new source after a drive mode change, 0 to switch at once")
 	flag.BoolVar(&enableObjectsCorrection, "enable-objects-correction", false, "Adjust steering to avoid objects")
 	flag.BoolVar(&enableObjectsCorrectionOnUserMode, "enable-objects-correction-user", false, "Adjust steering to avoid objects on user mode driving")
+	flag.Float64Var(&objectsMinConfidence, "objects-min-confidence", objectsMinConfidence, "Minimal confidence of objects to avoid, use OBJECTS_MIN_CONFIDENCE env if arg not set")
+	flag.BoolVar(&objectsConfidenceWeighting, "objects-confidence-weighting", objectsConfidenceWeighting, "Scale objects correction by detection confidence, if not set, true if OBJECTS_CONFIDENCE_WEIGHTING env variable is set")
+	flag.DurationVar(&objectsTTL, "objects-ttl", 0, "Delay after which objects are ignored if no new detection is received, 0 to disable")
+	flag.BoolVar(&enableFrameSync, "enable-frame-sync", false, "Correct tflite steering with objects detected on the same frame")
+	flag.IntVar(&frameSyncBufferSize, "frame-sync-buffer-size", 10, "Count of objects messages to keep to search frame, for frame synchronisation")
+	flag.DurationVar(&frameSyncTolerance, "frame-sync-tolerance", 100*time.Millisecond, "Max delay between steering frame and an earlier objects frame, for frame synchronisation")
+	flag.BoolVar(&enableTracking, "enable-objects-tracking", false, "Track objects across frames to smooth detections")
+	flag.IntVar(&trackerMinHits, "tracker-min-hits", 3, "Count of detections before to use a tracked object")
+	flag.IntVar(&trackerMaxMisses, "tracker-max-misses", 2, "Count of successive frames without detection before to drop a tracked object")
+	flag.Float64Var(&trackerIoUThreshold, "tracker-iou-threshold", 0.3, "Minimal intersection over union to associate a detection to a tracked object")
+	flag.Float64Var(&trackerSmoothing, "tracker-smoothing", 0.5, "Weight of new detection in tracked object, between 0 and 1")
+	flag.Float64Var(&steeringMaxRate, "steering-max-rate", 0., "Max steering variation, in units per second, 0 to disable")
+	flag.Float64Var(&steeringEMAAlpha, "steering-ema-alpha", 0., "Weight of new steering value for exponential moving average, 0 to disable")
+	flag.BoolVar(&enableOneEuro, "enable-steering-one-euro", false, "Smooth steering with One-Euro filter")
+	flag.BoolVar(&enableSteeringFiltersOnUserMode, "enable-steering-filters-user", false, "Smooth radio command steering too, only tflite steering is smoothed else")
+	flag.Float64Var(&oneEuroMinCutoff, "one-euro-min-cutoff", 1., "Minimal cutoff frequency in Hz, for One-Euro filter")
+	flag.Float64Var(&oneEuroBeta, "one-euro-beta", 0., "Speed coefficient, for One-Euro filter")
+	flag.Float64Var(&oneEuroDCutoff, "one-euro-d-cutoff", 1., "Cutoff frequency in Hz of derivative, for One-Euro filter")
 	flag.StringVar(&gridMapConfig, "grid-map-config", "", "Json file path to configure grid object correction")
+	flag.StringVar(&gridMapSlowConfig, "grid-map-config-slow", "", "Json file path to configure grid object correction in SLOW speed zone, use grid-map-config if not set")
+	flag.StringVar(&gridMapNormalConfig, "grid-map-config-normal", "", "Json file path to configure grid object correction in NORMAL speed zone, use grid-map-config if not set")
+	flag.StringVar(&gridMapFastConfig, "grid-map-config-fast", "", "Json file path to configure grid object correction in FAST speed zone, use grid-map-config if not set")
+	flag.Float64Var(&throttleGainAtStop, "throttle-gain-stop", 1., "Factor to apply on objects correction when car is stopped, applied permanently if throttle topic isn't set")
+	flag.Float64Var(&throttleGainAtFull, "throttle-gain-full", 1., "Factor to apply on objects correction at full throttle, needs throttle topic")
 	flag.StringVar(&objectsMoveFactorsConfig, "objects-move-factors-config", "", "Json file path to configure objects move corrections")
+	flag.StringVar(&objectsProfilesConfig, "objects-profiles-config", "", "Json file path to configure correction by type of object (CAR, BUMP, PLOT, ANY)")
+	flag.StringVar(&gridInterpolation, "grid-interpolation", "", "Interpolation mode to apply on grid maps (nearest|bilinear|bicubic), use json config value if not set")
+	flag.StringVar(&objectsStrategy, "objects-strategy", string(steering.StrategyMaxMagnitude), "Strategy to combine correction of several objects (max|weighted|gap)")
 	flag.Float64Var(&deltaMiddle, "delta-middle", 0.1, "Half Percent zone to interpret as straight")
+	flag.StringVar(&distanceSource, "distance-source", "depth", "Distance to use for grid map rows: object depth in mm when available with fallback to bottom position, or only bottom position (depth|bottom)")
+	flag.StringVar(&correctorType, "corrector", "grid", "Corrector implementation to use to avoid objects (grid|gap|ttc), ttc scales grid and speed zones correction by time to collision")
+	flag.DurationVar(&ttcHorizon, "ttc-horizon", 2*time.Second, "Time to collision below which objects avoidance is amplified, for ttc corrector")
+	flag.Float64Var(&ttcMaxGain, "ttc-max-gain", 1.5, "Factor to apply on grid correction for an imminent collision, for ttc corrector")
+	flag.Float64Var(&gapLookahead, "gap-lookahead", 0.6, "Height in image where objects are projected to search free corridors, for gap corrector")
+	flag.Float64Var(&gapSafetyMargin, "gap-safety-margin", 0.05, "Margin to add on each side of objects, for gap corrector")
+	flag.Float64Var(&gapMinCorridorWidth, "gap-min-corridor-width", 0.1, "Minimal width of free corridor, steering is kept at half this width from corridor borders, for gap corrector")
+	flag.StringVar(&pipelineConfig, "pipeline-config", "", "Json file path to declare steering processors pipeline (arbitration, road and objects correction, steering filters), flags of these stages are rejected if set. Drive mode transition and calibration are applied after declared pipeline unless declared by it")
+	flag.StringVar(&calibrationConfig, "calibration-config", os.Getenv("CALIBRATION_CONFIG"), "Json file path to configure steering calibration of the car (trim, gains, deadband, expo, saturation), use CALIBRATION_CONFIG env if arg not set")
 	logLevel := zap.LevelFlag("log", zap.InfoLevel, "log level")
 
 	flag.Parse()
@@ -60,12 +170,154 @@ func main() {
 	zap.S().Infof("steering topic                  : %s", steeringTopic)
 	zap.S().Infof("rc topic                        : %s", rcSteeringTopic)
 	zap.S().Infof("tflite steering topic           : %s", tfSteeringTopic)
+	zap.S().Infof("tflite steering ensemble topics : %s", tfSteeringEnsembleTopics)
+	zap.S().Infof("ensemble fusion                 : %s", ensembleFusion)
 	zap.S().Infof("drive mode topic                : %s", driveModeTopic)
 	zap.S().Infof("objects topic                   : %s", objectsTopic)
+	zap.S().Infof("throttle topic                  : %s", throttleTopic)
+	zap.S().Infof("speed zone topic                : %s", speedZoneTopic)
+	zap.S().Infof("road topic                      : %s", roadTopic)
+	zap.S().Infof("road correction enabled         : %v", enableRoadCorrection)
+	zap.S().Infof("confidence threshold            : %v", confidenceThreshold)
+	zap.S().Infof("confidence fallback             : %v", confidenceFallback)
+	zap.S().Infof("drive mode transition           : %v", transitionDuration)
+	zap.S().Infof("metrics address                 : %v", metricsAddr)
+	zap.S().Infof("watchdog timeout                : %v", watchdogTimeout)
+	zap.S().Infof("failsafe topic                  : %v", failsafeTopic)
+	zap.S().Infof("steering debug topic            : %v", steeringDebugTopic)
+	zap.S().Infof("failsafe steering               : %v", failsafeSteering)
+	zap.S().Infof("copilot authority               : %v", copilotAuthority)
+	zap.S().Infof("copilot override threshold      : %v", copilotOverrideThreshold)
 	zap.S().Infof("objects correction enabled      : %v", enableObjectsCorrection)
 	zap.S().Infof("objects correction on user mode : %v", enableObjectsCorrectionOnUserMode)
+	zap.S().Infof("objects ttl                     : %v", objectsTTL)
+	zap.S().Infof("frame sync enabled              : %v", enableFrameSync)
+	zap.S().Infof("objects tracking enabled        : %v", enableTracking)
+	zap.S().Infof("steering max rate               : %v", steeringMaxRate)
+	zap.S().Infof("steering ema alpha              : %v", steeringEMAAlpha)
+	zap.S().Infof("steering One-Euro filter        : %v", enableOneEuro)
+	zap.S().Infof("steering filters on user mode   : %v", enableSteeringFiltersOnUserMode)
+	zap.S().Infof("objects min confidence          : %v", objectsMinConfidence)
+	zap.S().Infof("objects confidence weighting    : %v", objectsConfidenceWeighting)
+	zap.S().Infof("corrector                       : %v", correctorType)
+	zap.S().Infof("distance source                 : %v", distanceSource)
 	zap.S().Infof("grid map file config            : %v", gridMapConfig)
+	zap.S().Infof("grid map slow/normal/fast config: %v/%v/%v", gridMapSlowConfig, gridMapNormalConfig, gridMapFastConfig)
+	zap.S().Infof("throttle gain at stop/full      : %v/%v", throttleGainAtStop, throttleGainAtFull)
 	zap.S().Infof("objects move factors grid config: %v", objectsMoveFactorsConfig)
+	zap.S().Infof("objects profiles config         : %v", objectsProfilesConfig)
+	zap.S().Infof("grid interpolation              : %v", gridInterpolation)
+	zap.S().Infof("objects strategy                : %v", objectsStrategy)
+	zap.S().Infof("pipeline config                 : %v", pipelineConfig)
+	zap.S().Infof("calibration config              : %v", calibrationConfig)
+
+	if conflicts := flagsSet(pipelineFlags...); pipelineConfig != "" && len(conflicts) > 0 {
+		zap.S().Fatalf("flags %v configure stages declared by pipeline config, remove them or declare stages in '%v'", strings.Join(conflicts, ", "), pipelineConfig)
+	}
+	if steeringEMAAlpha < 0. || steeringEMAAlpha > 1. {
+		zap.S().Fatalf("invalid steering ema alpha %v, must be in ]0,1], or 0 to disable", steeringEMAAlpha)
+	}
+
+	newGridCorrector := func(gridMapConfig string) *steering.GridCorrector {
+		gridMapOption, err := steering.WithGridMap(gridMapConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to configure grid map: %v", err)
+		}
+		objectMoveFactorsOption, err := steering.WithObjectMoveFactors(objectsMoveFactorsConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to configure objects move factors: %v", err)
+		}
+		profilesOption, err := steering.WithTypeProfiles(objectsProfilesConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to configure objects profiles: %v", err)
+		}
+		var ds steering.DistanceSource
+		switch distanceSource {
+		case "depth":
+			ds = steering.DepthDistance{}
+		case "bottom":
+			ds = steering.BottomDistance{}
+		default:
+			zap.S().Fatalf("invalid distance source '%v', must be 'depth' or 'bottom'", distanceSource)
+		}
+		interpolation := steering.Interpolation(gridInterpolation)
+		switch interpolation {
+		case "", steering.InterpolationNearest, steering.InterpolationBilinear, steering.InterpolationBicubic:
+		default:
+			zap.S().Fatalf("invalid grid interpolation '%v', must be 'nearest', 'bilinear' or 'bicubic'", gridInterpolation)
+		}
+		strategy := steering.ObjectsStrategy(objectsStrategy)
+		switch strategy {
+		case steering.StrategyMaxMagnitude, steering.StrategyWeightedSum, steering.StrategyFreeGap:
+		default:
+			zap.S().Fatalf("invalid objects strategy '%v', must be 'max', 'weighted' or 'gap'", objectsStrategy)
+		}
+		return steering.NewGridCorrector(
+			steering.WithDistanceSource(ds),
+			steering.WidthDeltaMiddle(deltaMiddle),
+			gridMapOption,
+			objectMoveFactorsOption,
+			profilesOption,
+			steering.WithInterpolation(interpolation),
+			steering.WithObjectsStrategy(strategy),
+		)
+	}
+	newTracker := func() *steering.Tracker {
+		return steering.NewTracker(
+			steering.WithMinHits(trackerMinHits),
+			steering.WithMaxMisses(trackerMaxMisses),
+			steering.WithIoUThreshold(trackerIoUThreshold),
+			steering.WithSmoothing(trackerSmoothing),
+		)
+	}
+	// newZoneCorrector builds grid correction of a speed zone, scaled by time to collision like default ttc corrector
+	newZoneCorrector := func(gridMapConfig string) steering.Corrector {
+		if correctorType != "ttc" {
+			return newGridCorrector(gridMapConfig)
+		}
+		return steering.NewTTCCorrector(
+			newGridCorrector(gridMapConfig),
+			steering.WithTTCTracker(newTracker()),
+			steering.WithTTCHorizon(ttcHorizon),
+			steering.WithTTCMaxGain(ttcMaxGain),
+		)
+	}
+
+	var corrector steering.Corrector
+	switch correctorType {
+	case "grid":
+		corrector = newGridCorrector(gridMapConfig)
+	case "gap":
+		corrector = steering.NewGapCorrector(
+			steering.WithLookahead(gapLookahead),
+			steering.WithSafetyMargin(gapSafetyMargin),
+			steering.WithMinCorridorWidth(gapMinCorridorWidth),
+		)
+	case "ttc":
+		corrector = newZoneCorrector(gridMapConfig)
+	default:
+		zap.S().Fatalf("invalid corrector '%v', must be 'grid', 'gap' or 'ttc'", correctorType)
+	}
+
+	var speedOptions []steering.OptionSpeedCorrector
+	for zone, config := range map[events.SpeedZone]string{
+		events.SpeedZone_SLOW:   gridMapSlowConfig,
+		events.SpeedZone_NORMAL: gridMapNormalConfig,
+		events.SpeedZone_FAST:   gridMapFastConfig,
+	} {
+		if config != "" {
+			speedOptions = append(speedOptions, steering.WithZoneCorrector(zone, newZoneCorrector(config)))
+		}
+	}
+	if throttleGainAtStop != 1. || throttleGainAtFull != 1. {
+		if throttleTopic == "" {
+			zap.S().Warnf("no throttle topic, objects correction is scaled by throttle gain at stop %v", throttleGainAtStop)
+		}
+		speedOptions = append(speedOptions, steering.WithThrottleGain(throttleGainAtStop, throttleGainAtFull))
+	}
+	if len(speedOptions) > 0 {
+		corrector = steering.NewSpeedCorrector(corrector, speedOptions...)
+	}
 
 	client, err := cli.Connect(mqttBroker, username, password, clientId)
 	if err != nil {
@@ -73,17 +325,158 @@ func main() {
 	}
 	defer client.Disconnect(50)
 
+	options := []steering.Option{
+		steering.WithCorrector(corrector),
+		steering.WithObjectsCorrectionEnabled(enableObjectsCorrection, enableObjectsCorrectionOnUserMode),
+		steering.WithObjectsFilter(steering.NewConfidenceFilter(objectsMinConfidence, objectsConfidenceWeighting)),
+		steering.WithObjectsTTL(objectsTTL),
+		steering.WithCopilot(steering.NewCopilot(
+			steering.WithAuthority(copilotAuthority),
+			steering.WithOverrideThreshold(copilotOverrideThreshold),
+			steering.WithAuthorityRecovery(copilotRecovery),
+			steering.WithModelTTL(copilotModelTTL),
+		)),
+	}
+	if enableTracking {
+		options = append(options, steering.WithTracker(newTracker()))
+	}
+	var filters []steering.SteeringFilter
+	if steeringEMAAlpha > 0. {
+		filters = append(filters, steering.NewEMAFilter(steeringEMAAlpha))
+	}
+	if enableOneEuro {
+		filters = append(filters, steering.NewOneEuroFilter(oneEuroMinCutoff, oneEuroBeta, oneEuroDCutoff))
+	}
+	if steeringMaxRate > 0. {
+		filters = append(filters, steering.NewSlewRateLimiter(steeringMaxRate))
+	}
+	if len(filters) > 0 {
+		options = append(options, steering.WithSteeringFilters(filters...), steering.WithSteeringFiltersOnUserMode(enableSteeringFiltersOnUserMode))
+	}
+	if pipelineConfig != "" {
+		processors, err := steering.LoadProcessors(pipelineConfig, corrector)
+		if err != nil {
+			zap.S().Fatalf("unable to load pipeline config: %v", err)
+		}
+		for _, p := range processors {
+			switch p.(type) {
+			case *steering.Transition:
+				if transitionDuration > 0 {
+					zap.S().Fatalf("flag -drive-mode-transition configures transition declared by pipeline config '%v'", pipelineConfig)
+				}
+			case *steering.Calibration:
+				if calibrationConfig != "" {
+					zap.S().Fatalf("flag -calibration-config configures calibration declared by pipeline config '%v'", pipelineConfig)
+				}
+			}
+		}
+		options = append(options, steering.WithProcessors(processors...))
+	}
+	if tfSteeringEnsembleTopics != "" {
+		fusion := steering.Fusion(ensembleFusion)
+		switch fusion {
+		case steering.FusionWeighted, steering.FusionMedian, steering.FusionMostConfident:
+		default:
+			zap.S().Fatalf("invalid ensemble fusion '%v', must be 'weighted', 'median' or 'most-confident'", ensembleFusion)
+		}
+		var topics []string
+		for _, t := range strings.Split(tfSteeringEnsembleTopics, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				topics = append(topics, t)
+			}
+		}
+		options = append(options, steering.WithEnsemble(topics,
+			steering.WithFusion(fusion),
+			steering.WithEnsembleWindow(ensembleWindow),
+		))
+	}
+	if throttleTopic != "" {
+		options = append(options, steering.WithThrottleTopic(throttleTopic))
+	}
+	if speedZoneTopic != "" {
+		options = append(options, steering.WithSpeedZoneTopic(speedZoneTopic))
+	}
+	if roadTopic != "" {
+		options = append(options, steering.WithRoadTopic(roadTopic))
+	}
+	roadCorrector := steering.NewRoadCorrector(
+		steering.WithRoadImageWidth(roadImageWidth),
+		steering.WithRoadWeight(roadWeight),
+		steering.WithRoadGains(roadOffsetGain, roadHeadingGain),
+		steering.WithRoadMinConfidence(roadMinConfidence),
+		steering.WithRoadFallback(roadFallbackConfidence),
+		steering.WithRoadTTL(roadTTL),
+	)
+	if enableRoadCorrection {
+		options = append(options, steering.WithRoadCorrector(roadCorrector))
+	}
+	if confidenceThreshold > 0. {
+		fallback := steering.Fallback(confidenceFallback)
+		switch fallback {
+		case steering.FallbackRoad, steering.FallbackLastGood, steering.FallbackStraight:
+		default:
+			zap.S().Fatalf("invalid confidence fallback '%v', must be 'road', 'last-good' or 'straight'", confidenceFallback)
+		}
+		options = append(options, steering.WithArbiter(steering.NewArbiter(confidenceThreshold,
+			steering.WithFallback(fallback),
+			steering.WithFallbackRoad(roadCorrector),
+			steering.WithBlend(confidenceBlend),
+			steering.WithLastGoodDecay(lastGoodDecay),
+		)))
+	}
+	if transitionDuration > 0 {
+		options = append(options, steering.WithTransition(transitionDuration))
+	}
+	if watchdogTimeout > 0 {
+		safeSteering := steering.SafeSteering(failsafeSteering)
+		if safeSteering != steering.SafeCentre && safeSteering != steering.SafeDecay {
+			zap.S().Fatalf("invalid failsafe steering '%v', must be 'centre' or 'decay'", failsafeSteering)
+		}
+		options = append(options, steering.WithWatchdog(
+			steering.NewWatchdog(watchdogTimeout,
+				steering.WithSafeSteering(safeSteering),
+				steering.WithSafeDecay(failsafeDecay),
+			),
+			failsafeTopic,
+		))
+	}
+	if calibrationConfig != "" {
+		calibration, err := steering.LoadCalibration(calibrationConfig)
+		if err != nil {
+			zap.S().Fatalf("unable to load calibration config: %v", err)
+		}
+		options = append(options, steering.WithCalibration(calibration))
+	}
+	if steeringDebugTopic != "" {
+		options = append(options, steering.WithDebugTopic(steeringDebugTopic))
+	}
+	if metricsAddr != "" {
+		metrics := steering.NewMetrics()
+		options = append(options, steering.WithMetrics(metrics))
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		listener, err := net.Listen("tcp", metricsAddr)
+		if err != nil {
+			zap.S().Fatalf("unable to listen metrics address %v: %v", metricsAddr, err)
+		}
+		go func() {
+			if err := http.Serve(listener, mux); err != nil {
+				zap.S().Errorf("unable to serve metrics on %v: %v", metricsAddr, err)
+			}
+		}()
+	}
+	if enableFrameSync {
+		frameSyncOption, err := steering.WithFrameSync(frameSyncBufferSize, frameSyncTolerance)
+		if err != nil {
+			zap.S().Fatalf("unable to configure frame sync: %v", err)
+		}
+		options = append(options, frameSyncOption)
+	}
+
 	p := steering.NewController(
 		client,
 		steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, objectsTopic,
-		steering.WithCorrector(
-			steering.NewGridCorrector(
-				steering.WidthDeltaMiddle(deltaMiddle),
-				steering.WithGridMap(gridMapConfig),
-				steering.WithObjectMoveFactors(objectsMoveFactorsConfig),
-			),
-		),
-		steering.WithObjectsCorrectionEnabled(enableObjectsCorrection, enableObjectsCorrectionOnUserMode),
+		options...,
 	)
 	defer p.Stop()
 
@@ -94,3 +487,16 @@ func main() {
 		zap.S().Fatalf("unable to start service: %v", err)
 	}
 }
+
+// flagsSet returns flags among names that are set on command line
+func flagsSet(names ...string) []string {
+	var set []string
+	flag.Visit(func(f *flag.Flag) {
+		for _, n := range names {
+			if f.Name == n {
+				set = append(set, "-"+n)
+			}
+		}
+	})
+	return set
+}
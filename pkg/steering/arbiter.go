@@ -0,0 +1,173 @@
+package steering
+
+import (
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+// Fallback defines steering to use when model confidence is too low
+type Fallback string
+
+const (
+	// FallbackRoad uses lane-keeping steering from road, with fallback to last good value if road isn't available
+	FallbackRoad Fallback = "road"
+	// FallbackLastGood uses last steering with enough confidence, decayed to straight over time
+	FallbackLastGood Fallback = "last-good"
+	// FallbackStraight drives straight ahead
+	FallbackStraight Fallback = "straight"
+)
+
+// arbitration is the steering source selected by Arbiter
+type arbitration string
+
+const (
+	arbitrationModel    arbitration = "model"
+	arbitrationRoad     arbitration = "road"
+	arbitrationLastGood arbitration = "last-good"
+	arbitrationStraight arbitration = "straight"
+)
+
+type OptionArbiter func(a *Arbiter)
+
+// WithFallback defines steering to use when model confidence is below threshold
+func WithFallback(f Fallback) OptionArbiter {
+	return func(a *Arbiter) {
+		a.fallback = f
+	}
+}
+
+// WithFallbackRoad defines road used by FallbackRoad
+func WithFallbackRoad(rc *RoadCorrector) OptionArbiter {
+	return func(a *Arbiter) {
+		a.road = rc
+	}
+}
+
+// WithBlend blends model and fallback steering proportionally to model confidence instead of switching
+func WithBlend(enabled bool) OptionArbiter {
+	return func(a *Arbiter) {
+		a.blend = enabled
+	}
+}
+
+// WithLastGoodDecay defines time constant of exponential decay of last good value to straight, 0 to disable decay
+func WithLastGoodDecay(tau time.Duration) OptionArbiter {
+	return func(a *Arbiter) {
+		a.decay = tau
+	}
+}
+
+func NewArbiter(threshold float64, options ...OptionArbiter) *Arbiter {
+	a := &Arbiter{
+		threshold: threshold,
+		fallback:  FallbackLastGood,
+		decay:     time.Second,
+		current:   arbitrationModel,
+	}
+	for _, o := range options {
+		o(a)
+	}
+	return a
+}
+
+/*
+Arbiter switches tflite steering to a fallback when model confidence is below threshold.
+
+With blend, output is confidence/threshold * model + (1 - confidence/threshold) * fallback, so that steering moves
+progressively to fallback when confidence drops. Output confidence is the confidence of the arbitrated value: model
+confidence, road ellipse confidence, last good confidence decayed as value or 0 for straight.
+
+Radio command steering is not arbitrated.
+*/
+type Arbiter struct {
+	threshold float64
+	fallback  Fallback
+	road      *RoadCorrector
+	blend     bool
+	decay     time.Duration
+
+	mu                 sync.Mutex
+	current            arbitration
+	lastGood           float64
+	lastGoodConfidence float64
+	lastGoodTime       time.Time
+}
+
+// OnRoad forwards road to fallback road
+func (a *Arbiter) OnRoad(road *events.RoadMessage, ts time.Time) {
+	if a.road != nil {
+		a.road.OnRoad(road, ts)
+	}
+}
+
+func (a *Arbiter) Process(s *Steering) {
+	if s.Source != SourceTF {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if s.Confidence >= a.threshold {
+		a.lastGood, a.lastGoodConfidence, a.lastGoodTime = s.Value, s.Confidence, s.Timestamp
+		a.switchTo(arbitrationModel, fmt.Sprintf("model confidence %v above threshold %v", s.Confidence, a.threshold))
+		return
+	}
+
+	value, confidence, source, reason := a.fallbackOf(s.Timestamp)
+	a.switchTo(source, fmt.Sprintf("model confidence %v below threshold %v, %v", s.Confidence, a.threshold, reason))
+
+	if a.blend && a.threshold > 0. {
+		w := math.Max(s.Confidence, 0.) / a.threshold
+		value = w*s.Value + (1.-w)*value
+		confidence = w*s.Confidence + (1.-w)*confidence
+	}
+	zap.S().Debugf("arbitrate steering: %v -> %v, confidence: %v -> %v", s.Value, value, s.Confidence, confidence)
+	s.Value, s.Confidence = value, confidence
+}
+
+// fallbackOf returns fallback steering, its confidence, source and reason of choice, lock must be held
+func (a *Arbiter) fallbackOf(ts time.Time) (float64, float64, arbitration, string) {
+	switch a.fallback {
+	case FallbackStraight:
+		return 0., 0., arbitrationStraight, "drive straight"
+	case FallbackRoad:
+		if a.road != nil {
+			if road, ok := a.road.estimate(ts); ok {
+				value, _ := a.road.Steering(ts)
+				return value, road.confidence, arbitrationRoad, "use road"
+			}
+		}
+		value, confidence := a.lastGoodAt(ts)
+		return value, confidence, arbitrationLastGood, "road not available, use last good value"
+	default:
+		value, confidence := a.lastGoodAt(ts)
+		return value, confidence, arbitrationLastGood, "use last good value"
+	}
+}
+
+// lastGoodAt returns last good value and confidence decayed at ts, lock must be held
+func (a *Arbiter) lastGoodAt(ts time.Time) (float64, float64) {
+	if a.lastGoodTime.IsZero() {
+		return 0., 0.
+	}
+	if a.decay <= 0 {
+		return a.lastGood, a.lastGoodConfidence
+	}
+	dt := math.Max(ts.Sub(a.lastGoodTime).Seconds(), 0.)
+	factor := math.Exp(-dt / a.decay.Seconds())
+	return a.lastGood * factor, a.lastGoodConfidence * factor
+}
+
+// switchTo logs source changes, lock must be held
+func (a *Arbiter) switchTo(source arbitration, reason string) {
+	if source == a.current {
+		return
+	}
+	zap.S().Infof("switch steering from %v to %v: %v", a.current, source, reason)
+	a.current = source
+}
@@ -0,0 +1,133 @@
+package steering
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestArbiter_Process(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		offset         time.Duration
+		steering       Steering
+		want           float64
+		wantConfidence float64
+		wantSource     arbitration
+	}
+	tests := []struct {
+		name    string
+		options []OptionArbiter
+		road    bool
+		steps   []step
+	}{
+		{
+			name:    "confident model",
+			options: []OptionArbiter{WithFallback(FallbackStraight)},
+			steps: []step{
+				{steering: Steering{Value: 0.4, Confidence: 0.8, Source: SourceTF}, want: 0.4, wantConfidence: 0.8, wantSource: arbitrationModel},
+			},
+		},
+		{
+			name:    "switch to straight",
+			options: []OptionArbiter{WithFallback(FallbackStraight)},
+			steps: []step{
+				{steering: Steering{Value: 0.4, Confidence: 0.8, Source: SourceTF}, want: 0.4, wantConfidence: 0.8, wantSource: arbitrationModel},
+				{offset: 50 * time.Millisecond, steering: Steering{Value: 0.6, Confidence: 0.2, Source: SourceTF}, want: 0., wantConfidence: 0., wantSource: arbitrationStraight},
+				{offset: 100 * time.Millisecond, steering: Steering{Value: 0.3, Confidence: 0.9, Source: SourceTF}, want: 0.3, wantConfidence: 0.9, wantSource: arbitrationModel},
+			},
+		},
+		{
+			name:    "rc steering not arbitrated",
+			options: []OptionArbiter{WithFallback(FallbackStraight)},
+			steps: []step{
+				{steering: Steering{Value: 0.6, Confidence: 0., Source: SourceRC}, want: 0.6, wantConfidence: 0., wantSource: arbitrationModel},
+			},
+		},
+		{
+			name:    "last good value with decay",
+			options: []OptionArbiter{WithFallback(FallbackLastGood), WithLastGoodDecay(time.Second)},
+			steps: []step{
+				{steering: Steering{Value: 0.5, Confidence: 0.8, Source: SourceTF}, want: 0.5, wantConfidence: 0.8, wantSource: arbitrationModel},
+				{offset: 0, steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0.5, wantConfidence: 0.8, wantSource: arbitrationLastGood},
+				{offset: time.Second, steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0.5 * math.Exp(-1), wantConfidence: 0.8 * math.Exp(-1), wantSource: arbitrationLastGood},
+			},
+		},
+		{
+			name:    "last good value without decay",
+			options: []OptionArbiter{WithFallback(FallbackLastGood), WithLastGoodDecay(0)},
+			steps: []step{
+				{steering: Steering{Value: 0.5, Confidence: 0.8, Source: SourceTF}, want: 0.5, wantConfidence: 0.8, wantSource: arbitrationModel},
+				{offset: 10 * time.Second, steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0.5, wantConfidence: 0.8, wantSource: arbitrationLastGood},
+			},
+		},
+		{
+			name:    "no last good value",
+			options: []OptionArbiter{WithFallback(FallbackLastGood)},
+			steps: []step{
+				{steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0., wantConfidence: 0., wantSource: arbitrationLastGood},
+			},
+		},
+		{
+			name:    "road",
+			options: []OptionArbiter{WithFallback(FallbackRoad)},
+			road:    true,
+			steps: []step{
+				{steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0.5, wantConfidence: 0.7, wantSource: arbitrationRoad},
+			},
+		},
+		{
+			name:    "road not available",
+			options: []OptionArbiter{WithFallback(FallbackRoad), WithLastGoodDecay(0)},
+			road:    true,
+			steps: []step{
+				{steering: Steering{Value: 0.2, Confidence: 0.9, Source: SourceTF}, want: 0.2, wantConfidence: 0.9, wantSource: arbitrationModel},
+				{offset: time.Second, steering: Steering{Value: -0.6, Confidence: 0.1, Source: SourceTF}, want: 0.2, wantConfidence: 0.9, wantSource: arbitrationLastGood},
+			},
+		},
+		{
+			name:    "blend",
+			options: []OptionArbiter{WithFallback(FallbackStraight), WithBlend(true)},
+			steps: []step{
+				{steering: Steering{Value: 0.8, Confidence: 0.25, Source: SourceTF}, want: 0.4, wantConfidence: 0.125, wantSource: arbitrationStraight},
+				{steering: Steering{Value: 0.8, Confidence: 0., Source: SourceTF}, want: 0., wantConfidence: 0., wantSource: arbitrationStraight},
+			},
+		},
+		{
+			name:    "blend with road",
+			options: []OptionArbiter{WithFallback(FallbackRoad), WithBlend(true)},
+			road:    true,
+			steps: []step{
+				{steering: Steering{Value: -0.5, Confidence: 0.25, Source: SourceTF}, want: 0., wantConfidence: 0.475, wantSource: arbitrationRoad},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			if tt.road {
+				rc := NewRoadCorrector()
+				options = append(options, WithFallbackRoad(rc))
+			}
+			a := NewArbiter(0.5, options...)
+			if tt.road {
+				a.OnRoad(roadMessage(120, 20, 100, 0., 0.7), start)
+			}
+			for i, st := range tt.steps {
+				s := st.steering
+				s.Timestamp = start.Add(st.offset)
+				a.Process(&s)
+				if math.Abs(s.Value-st.want) > 1e-6 {
+					t.Errorf("step %d: Process() = %v, want %v", i, s.Value, st.want)
+				}
+				if math.Abs(s.Confidence-st.wantConfidence) > 1e-6 {
+					t.Errorf("step %d: Process() confidence = %v, want %v", i, s.Confidence, st.wantConfidence)
+				}
+				if a.current != st.wantSource {
+					t.Errorf("step %d: source = %v, want %v", i, a.current, st.wantSource)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package steering
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// DefaultCalibration returns a calibration without effect on steering
+func DefaultCalibration() *Calibration {
+	return &Calibration{
+		LeftGain:  1.,
+		RightGain: 1.,
+		Min:       -1.,
+		Max:       1.,
+	}
+}
+
+/*
+Calibration adapts published steering to servo of a car. Steps are applied in order:
+
+ 1. Deadband: as deltaMiddle of GridCorrector, values in [-Deadband, Deadband] are interpreted as straight,
+    others are rescaled to keep continuity
+ 2. Expo: blend between linear and cubic response, 0 for linear response and 1 for cubic response
+ 3. Gains: scale left (negative) values by LeftGain and right (positive) values by RightGain
+ 4. Trim: offset to apply to centre servo
+ 5. Saturation: limit result to [Min, Max]
+*/
+type Calibration struct {
+	Trim      float64 `json:"trim"`
+	LeftGain  float64 `json:"left_gain"`
+	RightGain float64 `json:"right_gain"`
+	Deadband  float64 `json:"deadband"`
+	Expo      float64 `json:"expo"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+}
+
+// Validate checks calibration values and returns all errors found
+func (c *Calibration) Validate() error {
+	var errs []error
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"trim", c.Trim}, {"left_gain", c.LeftGain}, {"right_gain", c.RightGain},
+		{"deadband", c.Deadband}, {"expo", c.Expo}, {"min", c.Min}, {"max", c.Max},
+	} {
+		if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			errs = append(errs, fmt.Errorf("%s: invalid value %v", f.name, f.value))
+		}
+	}
+	if c.Trim < -1. || c.Trim > 1. {
+		errs = append(errs, fmt.Errorf("trim %v must be in [-1,1]", c.Trim))
+	}
+	if c.LeftGain <= 0. {
+		errs = append(errs, fmt.Errorf("left_gain %v must be positive", c.LeftGain))
+	}
+	if c.RightGain <= 0. {
+		errs = append(errs, fmt.Errorf("right_gain %v must be positive", c.RightGain))
+	}
+	if c.Deadband < 0. || c.Deadband >= 1. {
+		errs = append(errs, fmt.Errorf("deadband %v must be in [0,1[", c.Deadband))
+	}
+	if c.Expo < 0. || c.Expo > 1. {
+		errs = append(errs, fmt.Errorf("expo %v must be in [0,1]", c.Expo))
+	}
+	if c.Min < -1. || c.Max > 1. || c.Min >= c.Max {
+		errs = append(errs, fmt.Errorf("saturation [%v,%v] must be a range in [-1,1]", c.Min, c.Max))
+	}
+	return errors.Join(errs...)
+}
+
+// Apply returns calibrated steering
+func (c *Calibration) Apply(steering float64) float64 {
+	value := clamp(steering, -1., 1.)
+
+	if math.Abs(value) <= c.Deadband {
+		value = 0.
+	} else {
+		value = math.Copysign((math.Abs(value)-c.Deadband)/(1.-c.Deadband), value)
+	}
+
+	value = (1.-c.Expo)*value + c.Expo*value*value*value
+
+	if value < 0. {
+		value *= c.LeftGain
+	} else {
+		value *= c.RightGain
+	}
+
+	return clamp(value+c.Trim, c.Min, c.Max)
+}
+
+// Process applies calibration on steering, calibration must be the last stage before publication
+func (c *Calibration) Process(s *Steering) {
+	s.Value = c.Apply(s.Value)
+}
+
+/*
+LoadCalibration reads calibration of a car from json file, missing fields keep default values:
+
+	{
+	  "trim": 0.05,
+	  "left_gain": 0.9,
+	  "right_gain": 1.0,
+	  "deadband": 0.02,
+	  "expo": 0.3,
+	  "min": -0.95,
+	  "max": 1.0
+	}
+*/
+func LoadCalibration(configPath string) (*Calibration, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read calibration config from file '%v': %w", configPath, err)
+	}
+	c := DefaultCalibration()
+	err = json.Unmarshal(content, c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal json calibration config '%s': %w", configPath, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid json calibration config '%s': %w", configPath, err)
+	}
+	return c, nil
+}
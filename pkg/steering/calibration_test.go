@@ -0,0 +1,105 @@
+package steering
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCalibration_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration Calibration
+		steering    float64
+		want        float64
+	}{
+		{name: "default", calibration: *DefaultCalibration(), steering: 0.3, want: 0.3},
+		{name: "default out of range", calibration: *DefaultCalibration(), steering: 1.5, want: 1.},
+		{name: "trim", calibration: Calibration{Trim: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: 0., want: 0.1},
+		{name: "left gain", calibration: Calibration{LeftGain: 0.5, RightGain: 1, Min: -1, Max: 1}, steering: -0.6, want: -0.3},
+		{name: "right gain", calibration: Calibration{LeftGain: 0.5, RightGain: 0.8, Min: -1, Max: 1}, steering: 0.5, want: 0.4},
+		{name: "inside deadband", calibration: Calibration{Deadband: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: -0.08, want: 0.},
+		{name: "deadband limit", calibration: Calibration{Deadband: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: 0.1, want: 0.},
+		{name: "outside deadband", calibration: Calibration{Deadband: 0.2, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: 0.6, want: 0.5},
+		{name: "full lock with deadband", calibration: Calibration{Deadband: 0.2, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: -1., want: -1.},
+		{name: "expo", calibration: Calibration{Expo: 0.5, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: 0.5, want: 0.3125},
+		{name: "full expo", calibration: Calibration{Expo: 1., LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: -0.5, want: -0.125},
+		{name: "expo keeps full lock", calibration: Calibration{Expo: 0.7, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}, steering: 1., want: 1.},
+		{name: "saturation max", calibration: Calibration{LeftGain: 1, RightGain: 1, Min: -1, Max: 0.7}, steering: 0.9, want: 0.7},
+		{name: "saturation min with trim", calibration: Calibration{Trim: -0.2, LeftGain: 1, RightGain: 1, Min: -0.9, Max: 1}, steering: -0.8, want: -0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calibration.Apply(tt.steering); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibration_Monotonic(t *testing.T) {
+	c := Calibration{Trim: 0.05, LeftGain: 0.8, RightGain: 1.1, Deadband: 0.1, Expo: 0.4, Min: -0.9, Max: 0.95}
+	last := c.Apply(-1.)
+	for s := -1.; s <= 1.; s += 0.01 {
+		got := c.Apply(s)
+		if got < last {
+			t.Errorf("Apply(%v) = %v, lower than previous value %v", s, got, last)
+		}
+		last = got
+	}
+}
+
+func TestLoadCalibration(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		want     *Calibration
+		wantErrs []string
+	}{
+		{
+			name:   "partial config with default values",
+			config: "test_data/calibration.json",
+			want:   &Calibration{Trim: 0.05, LeftGain: 0.8, RightGain: 1., Deadband: 0.1, Expo: 0., Min: -0.9, Max: 1.},
+		},
+		{
+			name:   "invalid config",
+			config: "test_data/invalid-calibration.json",
+			wantErrs: []string{
+				"left_gain 0 must be positive",
+				"deadband 1 must be in [0,1[",
+				"expo 2 must be in [0,1]",
+				"saturation [0.5,0.2] must be a range in [-1,1]",
+			},
+		},
+		{
+			name:     "missing file",
+			config:   "test_data/missing.json",
+			wantErrs: []string{"unable to read calibration config"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadCalibration(tt.config)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Fatalf("LoadCalibration() error = %v, wantErrs %v", err, tt.wantErrs)
+			}
+			for _, e := range tt.wantErrs {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("LoadCalibration() error = %v, want contains '%v'", err, e)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadCalibration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibration_Process(t *testing.T) {
+	s := Steering{Value: -0.5}
+	(&Calibration{Trim: 0.1, LeftGain: 0.5, RightGain: 1, Min: -1, Max: 1}).Process(&s)
+	if math.Abs(s.Value-(-0.15)) > 1e-9 {
+		t.Errorf("Process() = %v, want %v", s.Value, -0.15)
+	}
+}
@@ -1,6 +1,7 @@
 package steering
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/cyrilix/robocar-base/service"
 	"github.com/cyrilix/robocar-protobuf/go/events"
@@ -8,6 +9,8 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
@@ -50,6 +53,165 @@ func WithObjectsCorrectionEnabled(enabled, enabledOnUserDrive bool) Option {
 	}
 }
 
+// WithObjectsFilter defines filter to apply on received objects before correction, objects correction is weighted by
+// filter if it implements ObjectsWeighting
+func WithObjectsFilter(f ObjectsFilter) Option {
+	return func(ctrl *Controller) {
+		ctrl.objectsFilter = f
+		ctrl.objectWeight = nil
+		if w, ok := f.(ObjectsWeighting); ok {
+			ctrl.objectWeight = w.Weighting()
+		}
+	}
+}
+
+// WithObjectsTTL defines delay after which received objects are considered as stale, 0 to keep objects forever
+func WithObjectsTTL(ttl time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.objectsTTL = ttl
+	}
+}
+
+// WithClock overrides function used to get current time
+func WithClock(now func() time.Time) Option {
+	return func(ctrl *Controller) {
+		ctrl.now = now
+	}
+}
+
+/*
+WithFrameSync corrects tflite steering with objects detected on the same frame, or on the closest earlier frame within
+tolerance. The last bufferSize objects messages are kept to search matching frame, bufferSize must be at least 1.
+*/
+func WithFrameSync(bufferSize int, tolerance time.Duration) (Option, error) {
+	if bufferSize < 1 {
+		return nil, fmt.Errorf("invalid frame sync buffer size %v, must be at least 1", bufferSize)
+	}
+	return func(ctrl *Controller) {
+		ctrl.objectsBuffer = newObjectsBuffer(bufferSize, tolerance)
+	}, nil
+}
+
+// WithTracker tracks objects across frames, only confirmed and smoothed objects are used for correction
+func WithTracker(t *Tracker) Option {
+	return func(ctrl *Controller) {
+		ctrl.tracker = t
+	}
+}
+
+// WithSteeringFilters smooths steering values after correction, filters are applied in order
+func WithSteeringFilters(filters ...SteeringFilter) Option {
+	return func(ctrl *Controller) {
+		ctrl.filters = filters
+	}
+}
+
+// WithSteeringFiltersOnUserMode smooths radio command steering too, only tflite steering is smoothed else
+func WithSteeringFiltersOnUserMode(enabled bool) Option {
+	return func(ctrl *Controller) {
+		ctrl.filtersOnUser = enabled
+	}
+}
+
+/*
+WithProcessors defines steering pipeline. It replaces default pipeline built from WithCorrector,
+WithObjectsCorrectionEnabled, WithSteeringFilters, WithSteeringFiltersOnUserMode, WithRoadCorrector and WithArbiter
+options. Transition and Calibration stages declared in processors are used by controller on drive mode change and
+watchdog, else stages of WithTransition and WithCalibration options are applied after processors.
+*/
+func WithProcessors(processors ...Processor) Option {
+	return func(ctrl *Controller) {
+		ctrl.processors = processors
+	}
+}
+
+// WithThrottleTopic subscribes to throttle messages, speed is available to SpeedAwareCorrector
+func WithThrottleTopic(topic string) Option {
+	return func(ctrl *Controller) {
+		ctrl.throttleTopic = topic
+	}
+}
+
+// WithSpeedZoneTopic subscribes to speed zone messages, speed is available to SpeedAwareCorrector
+func WithSpeedZoneTopic(topic string) Option {
+	return func(ctrl *Controller) {
+		ctrl.speedZoneTopic = topic
+	}
+}
+
+// WithRoadTopic subscribes to road messages, roads are forwarded to pipeline stages implementing RoadObserver
+func WithRoadTopic(topic string) Option {
+	return func(ctrl *Controller) {
+		ctrl.roadTopic = topic
+	}
+}
+
+// WithRoadCorrector adds lane-keeping correction to default pipeline, before objects correction
+func WithRoadCorrector(rc *RoadCorrector) Option {
+	return func(ctrl *Controller) {
+		ctrl.roadCorrector = rc
+	}
+}
+
+// WithArbiter adds confidence arbitration at the beginning of default pipeline
+func WithArbiter(a *Arbiter) Option {
+	return func(ctrl *Controller) {
+		ctrl.arbiter = a
+	}
+}
+
+// WithCopilot defines how radio command and tflite steering are blended on COPILOT drive mode
+func WithCopilot(copilot *Copilot) Option {
+	return func(ctrl *Controller) {
+		ctrl.copilot = copilot
+	}
+}
+
+// WithEnsemble subscribes to steering of several tflite models, in place of tfSteeringTopic, and fuses them
+func WithEnsemble(topics []string, options ...OptionEnsemble) Option {
+	return func(ctrl *Controller) {
+		ctrl.ensemble = NewEnsemble(topics, options...)
+		ctrl.ensembleTimers = make(map[string]*time.Timer)
+		ctrl.tfSteeringTopics = topics
+	}
+}
+
+// WithWatchdog publishes safe steering when active source stops, and an alert on failsafeTopic if not empty
+func WithWatchdog(w *Watchdog, failsafeTopic string) Option {
+	return func(ctrl *Controller) {
+		ctrl.watchdog = w
+		ctrl.failsafeTopic = failsafeTopic
+	}
+}
+
+// WithTransition ramps steering over duration after a drive mode change, 0 to switch source at once
+func WithTransition(duration time.Duration) Option {
+	return func(ctrl *Controller) {
+		ctrl.transition = NewTransition(duration)
+	}
+}
+
+// WithDebugTopic publishes a JSON DebugRecord on topic for each steering output
+func WithDebugTopic(topic string) Option {
+	return func(ctrl *Controller) {
+		ctrl.debugTopic = topic
+	}
+}
+
+// WithMetrics collects activity of controller
+func WithMetrics(m *Metrics) Option {
+	return func(ctrl *Controller) {
+		ctrl.metrics = m
+	}
+}
+
+// WithCalibration applies calibration of car servo on steering, after all other pipeline stages
+func WithCalibration(calibration *Calibration) Option {
+	return func(ctrl *Controller) {
+		ctrl.calibration = calibration
+	}
+}
+
 func NewController(client mqtt.Client, steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, objectsTopic string, options ...Option) *Controller {
 	c := &Controller{
 		client:          client,
@@ -60,13 +222,54 @@ func NewController(client mqtt.Client, steeringTopic, driveModeTopic, rcSteering
 		objectsTopic:    objectsTopic,
 		driveMode:       events.DriveMode_USER,
 		corrector:       NewGridCorrector(),
+		copilot:         NewCopilot(),
+		now:             time.Now,
 	}
 	for _, o := range options {
 		o(c)
 	}
+	c.metrics.DriveMode(c.driveMode)
+	if c.processors == nil {
+		c.processors = c.defaultProcessors()
+	}
+
+	// Transition and calibration declared in pipeline replace options ones, others are applied after pipeline
+	transitionDeclared, calibrationDeclared := false, false
+	for _, p := range c.processors {
+		switch stage := p.(type) {
+		case *Transition:
+			c.transition, transitionDeclared = stage, true
+		case *Calibration:
+			c.calibration, calibrationDeclared = stage, true
+		}
+	}
+	if c.transition != nil && !transitionDeclared {
+		c.processors = append(c.processors, c.transition)
+	}
+	if c.calibration != nil && !calibrationDeclared {
+		c.processors = append(c.processors, c.calibration)
+	}
 	return c
 }
 
+// defaultProcessors returns arbitration, road correction and objects correction, if enabled, followed by steering filters
+func (c *Controller) defaultProcessors() []Processor {
+	processors := make([]Processor, 0, len(c.filters)+3)
+	if c.arbiter != nil {
+		processors = append(processors, c.arbiter)
+	}
+	if c.roadCorrector != nil {
+		processors = append(processors, c.roadCorrector)
+	}
+	if c.enableCorrection {
+		processors = append(processors, NewCorrectorProcessor(c.corrector, c.enableCorrectionOnUser))
+	}
+	for _, f := range c.filters {
+		processors = append(processors, NewFilterProcessor(f, c.filtersOnUser))
+	}
+	return processors
+}
+
 type Controller struct {
 	client        mqtt.Client
 	steeringTopic string
@@ -77,10 +280,43 @@ type Controller struct {
 	cancel                                                         chan interface{}
 	driveModeTopic, rcSteeringTopic, tfSteeringTopic, objectsTopic string
 
-	muObjects sync.RWMutex
-	objects   []*events.Object
+	throttleTopic, speedZoneTopic, roadTopic string
+	// tfSteeringTopics replaces tfSteeringTopic when ensemble is defined
+	tfSteeringTopics []string
+	ensemble         *Ensemble
+	// ensembleTimers flush pending frames of ensemble at the end of window, by frame key
+	muEnsembleTimers sync.Mutex
+	ensembleTimers   map[string]*time.Timer
+	muSpeed          sync.RWMutex
+	speed            Speed
+
+	muObjects     sync.RWMutex
+	objects       objectsSnapshot
+	objectsFilter ObjectsFilter
+	objectWeight  func(o *events.Object) float64
+	tracker       *Tracker
+	objectsTTL    time.Duration
+	// objectsStale is set when stale objects have been reported
+	objectsStale atomic.Bool
+	// objectsBuffer is defined only if frame synchronisation is enabled
+	objectsBuffer   *objectsBuffer
+	unmatchedFrames atomic.Uint64
+
+	now func() time.Time
 
 	corrector              Corrector
+	roadCorrector          *RoadCorrector
+	arbiter                *Arbiter
+	copilot                *Copilot
+	watchdog               *Watchdog
+	failsafeTopic          string
+	debugTopic             string
+	filters                []SteeringFilter
+	filtersOnUser          bool
+	processors             []Processor
+	transition             *Transition
+	calibration            *Calibration
+	metrics                *Metrics
 	enableCorrection       bool
 	enableCorrectionOnUser bool
 }
@@ -92,47 +328,240 @@ func (c *Controller) Start() error {
 	}
 
 	c.cancel = make(chan interface{})
+	if c.watchdog != nil {
+		go c.runWatchdog(c.cancel)
+	}
 	<-c.cancel
 	return nil
 }
 
+// runWatchdog checks input of active source until cancel is closed
+func (c *Controller) runWatchdog(cancel <-chan interface{}) {
+	period := max(c.watchdog.Timeout()/4, 10*time.Millisecond)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cancel:
+			return
+		case <-ticker.C:
+			c.checkWatchdog()
+		}
+	}
+}
+
+// checkWatchdog publishes safe steering, and an alert when fault is raised, if active source is missing
+func (c *Controller) checkWatchdog() {
+	c.muDriveMode.RLock()
+	defer c.muDriveMode.RUnlock()
+
+	var active Source
+	switch c.driveMode {
+	case events.DriveMode_USER, events.DriveMode_COPILOT:
+		active = SourceRC
+	case events.DriveMode_PILOT:
+		active = SourceTF
+	default:
+		return
+	}
+
+	centre := 0.
+	if c.calibration != nil {
+		centre = c.calibration.Apply(0.)
+	}
+	value, fault, raised := c.watchdog.Check(active, centre, c.now())
+	if !fault {
+		return
+	}
+	if raised {
+		zap.S().Errorf("failsafe: no %v steering since %v on %v drive mode, publish safe steering", active, c.watchdog.Timeout(), c.driveMode)
+		if c.failsafeTopic != "" {
+			alert, err := proto.Marshal(&events.DriveModeMessage{DriveMode: events.DriveMode_USER})
+			if err != nil {
+				zap.S().Errorf("unable to marshal failsafe alert: %v", err)
+			} else {
+				publish(c.client, c.failsafeTopic, &alert)
+			}
+		}
+	}
+
+	payload, err := proto.Marshal(&events.SteeringMessage{Steering: float32(value), Confidence: 0.})
+	if err != nil {
+		zap.S().Errorf("unable to marshal safe steering message: %v", err)
+		return
+	}
+	publish(c.client, c.steeringTopic, &payload)
+}
+
+// Fault returns true while watchdog detects missing input on active source
+func (c *Controller) Fault() bool {
+	if c.watchdog == nil {
+		return false
+	}
+	return c.watchdog.Fault()
+}
+
 func (c *Controller) Stop() {
 	close(c.cancel)
-	service.StopService("throttle", c.client, c.driveModeTopic, c.rcSteeringTopic, c.tfSteeringTopic)
+	c.muEnsembleTimers.Lock()
+	for key, timer := range c.ensembleTimers {
+		timer.Stop()
+		delete(c.ensembleTimers, key)
+	}
+	c.muEnsembleTimers.Unlock()
+	topics := []string{c.driveModeTopic, c.rcSteeringTopic}
+	if c.ensemble != nil {
+		topics = append(topics, c.tfSteeringTopics...)
+	} else {
+		topics = append(topics, c.tfSteeringTopic)
+	}
+	for _, t := range []string{c.throttleTopic, c.speedZoneTopic, c.roadTopic} {
+		if t != "" {
+			topics = append(topics, t)
+		}
+	}
+	service.StopService("throttle", c.client, topics...)
+}
+
+func (c *Controller) onThrottle(_ mqtt.Client, message mqtt.Message) {
+	c.metrics.MessageReceived(message.Topic())
+	var msg events.ThrottleMessage
+	err := proto.Unmarshal(message.Payload(), &msg)
+	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
+		zap.S().Errorf("unable to unmarshal throttle message: %v", err)
+		return
+	}
+
+	c.muSpeed.Lock()
+	defer c.muSpeed.Unlock()
+	c.speed.Throttle = float64(msg.GetThrottle())
+}
+
+func (c *Controller) onSpeedZone(_ mqtt.Client, message mqtt.Message) {
+	c.metrics.MessageReceived(message.Topic())
+	var msg events.SpeedZoneMessage
+	err := proto.Unmarshal(message.Payload(), &msg)
+	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
+		zap.S().Errorf("unable to unmarshal speed zone message: %v", err)
+		return
+	}
+
+	c.muSpeed.Lock()
+	defer c.muSpeed.Unlock()
+	c.speed.Zone = msg.GetSpeedZone()
+}
+
+// Speed returns last throttle and speed zone received
+func (c *Controller) Speed() Speed {
+	c.muSpeed.RLock()
+	defer c.muSpeed.RUnlock()
+	return c.speed
 }
 
 func (c *Controller) onObjects(_ mqtt.Client, message mqtt.Message) {
+	c.metrics.MessageReceived(message.Topic())
 	var msg events.ObjectsMessage
 	err := proto.Unmarshal(message.Payload(), &msg)
 	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
 		zap.S().Errorf("unable to unmarshal protobuf %T message: %v", msg, err)
 		return
 	}
 
+	objects := msg.GetObjects()
+	if c.objectsFilter != nil {
+		objects = c.objectsFilter.Filter(objects)
+	}
+
+	snapshot := objectsSnapshot{
+		receivedAt: c.now(),
+		frameId:    msg.GetFrameRef().GetId(),
+	}
+	if msg.GetFrameRef().GetCreatedAt() != nil {
+		snapshot.createdAt = msg.GetFrameRef().GetCreatedAt().AsTime()
+	}
+	if c.tracker != nil {
+		objects = c.tracker.Update(objects, snapshot.timestamp())
+	}
+	snapshot.objects = objects
+	for _, p := range c.processors {
+		if observer, ok := p.(ObjectsObserver); ok {
+			observer.OnObjects(objects, snapshot.timestamp())
+		}
+	}
+	if c.objectsBuffer != nil {
+		c.objectsBuffer.add(snapshot)
+	}
+
 	c.muObjects.Lock()
 	defer c.muObjects.Unlock()
-	c.objects = msg.GetObjects()
-	zap.S().Debugf("%v object(s) received", len(c.objects))
+	c.objects = snapshot
+	c.objectsStale.Store(false)
+	c.metrics.Objects(len(objects))
+	zap.S().Debugf("%v object(s) received", len(objects))
+}
+
+func (c *Controller) onRoad(_ mqtt.Client, message mqtt.Message) {
+	c.metrics.MessageReceived(message.Topic())
+	var msg events.RoadMessage
+	err := proto.Unmarshal(message.Payload(), &msg)
+	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
+		zap.S().Errorf("unable to unmarshal road message: %v", err)
+		return
+	}
+
+	ts := c.now()
+	if msg.GetFrameRef().GetCreatedAt() != nil {
+		ts = msg.GetFrameRef().GetCreatedAt().AsTime()
+	}
+	for _, p := range c.processors {
+		if observer, ok := p.(RoadObserver); ok {
+			observer.OnRoad(&msg, ts)
+		}
+	}
 }
 
 func (c *Controller) onDriveMode(_ mqtt.Client, message mqtt.Message) {
+	c.metrics.MessageReceived(message.Topic())
 	var msg events.DriveModeMessage
 	err := proto.Unmarshal(message.Payload(), &msg)
 	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
 		zap.S().Errorf("unable to unmarshal protobuf %T message: %v", msg, err)
 		return
 	}
 
+	if msg.GetDriveMode() == events.DriveMode_INVALID {
+		zap.S().Warnf("invalid drive mode received, keep current drive mode")
+		return
+	}
+
 	c.muDriveMode.Lock()
 	defer c.muDriveMode.Unlock()
+	if c.driveMode == msg.GetDriveMode() {
+		return
+	}
+	zap.S().Infof("drive mode changes from %v to %v", c.driveMode, msg.GetDriveMode())
+	if c.watchdog != nil {
+		c.watchdog.Arm(c.now())
+	}
+	if c.transition != nil {
+		c.transition.Start(c.now())
+	}
 	c.driveMode = msg.GetDriveMode()
+	c.metrics.DriveMode(c.driveMode)
 }
 
 func (c *Controller) onRCSteering(_ mqtt.Client, message mqtt.Message) {
+	receivedAt := c.now()
+	c.metrics.MessageReceived(message.Topic())
 	c.muDriveMode.RLock()
 	defer c.muDriveMode.RUnlock()
 
-	if c.driveMode != events.DriveMode_USER {
+	if c.driveMode != events.DriveMode_USER && c.driveMode != events.DriveMode_COPILOT {
 		return
 	}
 
@@ -140,22 +569,32 @@ func (c *Controller) onRCSteering(_ mqtt.Client, message mqtt.Message) {
 	evt := &events.SteeringMessage{}
 	err := proto.Unmarshal(payload, evt)
 	if err != nil {
-		zap.S().Debugf("unable to unmarshal rc event: %v", err)
-	} else {
-		zap.S().Debugf("receive steering message from radio command: %0.00f", evt.GetSteering())
-	}
-
-	if c.enableCorrection && c.enableCorrectionOnUser {
-		payload, err = c.adjustSteering(evt)
-		if err != nil {
-			zap.S().Errorf("unable to adjust steering, skip message: %v", err)
+		c.metrics.UnmarshalError(message.Topic())
+		if c.driveMode == events.DriveMode_USER {
+			zap.S().Errorf("unable to unmarshal rc event, forward it as is: %v", err)
+			publish(c.client, c.steeringTopic, &payload)
 			return
 		}
+		zap.S().Errorf("unable to unmarshal rc event: %v", err)
+		return
 	}
-	publish(c.client, c.steeringTopic, &payload)
+	zap.S().Debugf("receive steering message from radio command: %0.00f", evt.GetSteering())
+
+	if c.watchdog != nil {
+		c.watchdog.Feed(SourceRC, c.now())
+	}
+	if c.driveMode == events.DriveMode_COPILOT {
+		steering, confidence := c.copilot.OnRC(float64(evt.GetSteering()), float64(evt.GetConfidence()), c.now())
+		evt.Steering, evt.Confidence = float32(steering), float32(confidence)
+		c.processSteering(evt, nil, SourceCopilot, c.Objects, receivedAt)
+		return
+	}
+	c.processSteering(evt, payload, SourceRC, c.Objects, receivedAt)
 }
 
 func (c *Controller) onTFSteering(_ mqtt.Client, message mqtt.Message) {
+	receivedAt := c.now()
+	c.metrics.MessageReceived(message.Topic())
 	c.muDriveMode.RLock()
 	defer c.muDriveMode.RUnlock()
 	if c.driveMode != events.DriveMode_PILOT && c.driveMode != events.DriveMode_COPILOT {
@@ -166,49 +605,242 @@ func (c *Controller) onTFSteering(_ mqtt.Client, message mqtt.Message) {
 	evt := &events.SteeringMessage{}
 	err := proto.Unmarshal(message.Payload(), evt)
 	if err != nil {
+		c.metrics.UnmarshalError(message.Topic())
 		zap.S().Errorf("unable to unmarshal tensorflow event: %v", err)
 		return
-	} else {
-		zap.S().Debugf("receive steering message from tensorflow: %0.00f", evt.GetSteering())
 	}
+	zap.S().Debugf("receive steering message from tensorflow: %0.00f", evt.GetSteering())
 
-	payload := message.Payload()
-	if c.enableCorrection {
-		payload, err = c.adjustSteering(evt)
+	c.handleTFSteering(evt, message.Payload(), receivedAt)
+}
+
+// onEnsembleSteering returns callback that fuses steering of tflite model published on topic with other models
+func (c *Controller) onEnsembleSteering(topic string) mqtt.MessageHandler {
+	return func(_ mqtt.Client, message mqtt.Message) {
+		receivedAt := c.now()
+		c.metrics.MessageReceived(message.Topic())
+		c.muDriveMode.RLock()
+		defer c.muDriveMode.RUnlock()
+		if c.driveMode != events.DriveMode_PILOT && c.driveMode != events.DriveMode_COPILOT {
+			// User mode, skip new message
+			return
+		}
+
+		evt := &events.SteeringMessage{}
+		err := proto.Unmarshal(message.Payload(), evt)
 		if err != nil {
-			zap.S().Errorf("unable to adjust steering, skip message: %v", err)
+			c.metrics.UnmarshalError(message.Topic())
+			zap.S().Errorf("unable to unmarshal tensorflow event from %v: %v", topic, err)
 			return
 		}
+		zap.S().Debugf("receive steering message from tensorflow model %v: %0.00f", topic, evt.GetSteering())
+
+		key := frameKey(evt.GetFrameRef())
+		fused, ok := c.ensemble.Add(topic, evt, receivedAt)
+		if !ok {
+			c.startEnsembleTimer(key)
+			return
+		}
+		c.stopEnsembleTimers(key)
+		if key != "" {
+			// Fused steering is received with the first steering of frame
+			receivedAt = c.ensemble.receivedAt(key)
+		}
+		c.handleTFSteering(fused, nil, receivedAt)
+	}
+}
+
+// startEnsembleTimer flushes frame at the end of ensemble window, only one timer is started for a pending frame
+func (c *Controller) startEnsembleTimer(key string) {
+	c.muEnsembleTimers.Lock()
+	defer c.muEnsembleTimers.Unlock()
+	if _, ok := c.ensembleTimers[key]; ok || !c.ensemble.pendingFrame(key) {
+		return
+	}
+	c.ensembleTimers[key] = time.AfterFunc(c.ensemble.Window(), c.flushEnsemble)
+}
+
+// stopEnsembleTimers stops timers of frames that are no more pending
+func (c *Controller) stopEnsembleTimers(keys ...string) {
+	c.muEnsembleTimers.Lock()
+	defer c.muEnsembleTimers.Unlock()
+	for _, key := range keys {
+		if timer, ok := c.ensembleTimers[key]; ok {
+			timer.Stop()
+			delete(c.ensembleTimers, key)
+		}
 	}
+}
+
+// flushEnsemble processes frames for which not all models have sent steering before end of window
+func (c *Controller) flushEnsemble() {
+	c.muDriveMode.RLock()
+	defer c.muDriveMode.RUnlock()
+
+	expired := c.ensemble.Expire(c.now())
+	keys := make([]string, 0, len(expired))
+	for _, evt := range expired {
+		keys = append(keys, frameKey(evt.GetFrameRef()))
+	}
+	c.stopEnsembleTimers(keys...)
+	if c.driveMode != events.DriveMode_PILOT && c.driveMode != events.DriveMode_COPILOT {
+		return
+	}
+	for i, evt := range expired {
+		c.handleTFSteering(evt, nil, c.ensemble.receivedAt(keys[i]))
+	}
+}
+
+/*
+handleTFSteering processes steering from tflite received at receivedAt, raw is the received payload of evt if any, drive
+mode lock must be held
+*/
+func (c *Controller) handleTFSteering(evt *events.SteeringMessage, raw []byte, receivedAt time.Time) {
+	if c.watchdog != nil {
+		c.watchdog.Feed(SourceTF, c.now())
+	}
+	objects := func() []*events.Object {
+		return c.objectsOfFrame(evt.GetFrameRef())
+	}
+	if c.driveMode == events.DriveMode_COPILOT {
+		steering, confidence := c.copilot.OnModel(float64(evt.GetSteering()), float64(evt.GetConfidence()), c.now())
+		evt.Steering, evt.Confidence = float32(steering), float32(confidence)
+		c.processSteering(evt, nil, SourceCopilot, objects, receivedAt)
+		return
+	}
+	c.processSteering(evt, raw, SourceTF, objects, receivedAt)
+}
 
+/*
+processSteering applies pipeline on steering message received at receivedAt and publishes result, drive mode lock must
+be held. Raw payload of message, if not nil, is published as is when pipeline doesn't change steering.
+*/
+func (c *Controller) processSteering(evt *events.SteeringMessage, raw []byte, source Source, objects func() []*events.Object, receivedAt time.Time) {
+	s := &Steering{
+		Value:        float64(evt.GetSteering()),
+		Confidence:   float64(evt.GetConfidence()),
+		Source:       source,
+		DriveMode:    c.driveMode,
+		Speed:        c.Speed(),
+		FrameRef:     evt.GetFrameRef(),
+		Timestamp:    receivedAt,
+		ReceivedAt:   receivedAt,
+		objects:      objects,
+		objectWeight: c.objectWeight,
+	}
+	if evt.GetFrameRef().GetCreatedAt() != nil {
+		s.Timestamp = evt.GetFrameRef().GetCreatedAt().AsTime()
+	}
+
+	input := s.Value
+	for _, p := range c.processors {
+		p.Process(s)
+	}
+
+	if c.watchdog != nil {
+		c.watchdog.Published(s.Value)
+	}
+	payload := raw
+	if payload == nil || s.Value != input || s.Confidence != float64(evt.GetConfidence()) {
+		evt.Steering = float32(s.Value)
+		evt.Confidence = float32(s.Confidence)
+		var err error
+		payload, err = proto.Marshal(evt)
+		if err != nil {
+			zap.S().Errorf("unable to marshal steering message with new value, skip message: %v", err)
+			return
+		}
+	}
 	publish(c.client, c.steeringTopic, &payload)
+	if s.Correction != 0. {
+		c.metrics.CorrectionApplied(s.Correction)
+	}
+	c.metrics.Published(c.now().Sub(s.ReceivedAt))
+
+	if c.debugTopic != "" {
+		c.publishDebug(NewDebugRecord(input, s))
+	}
 }
 
-func (c *Controller) adjustSteering(evt *events.SteeringMessage) ([]byte, error) {
-	steering := float64(evt.GetSteering())
-	steering = c.corrector.AdjustFromObjectPosition(steering, c.Objects())
-	zap.S().Debugf("adjust steering to avoid objects: %v -> %v", evt.GetSteering(), steering)
-	evt.Steering = float32(steering)
-	// override payload content
-	payload, err := proto.Marshal(evt)
+// publishDebug publishes record as JSON on debug topic
+func (c *Controller) publishDebug(record *DebugRecord) {
+	payload, err := json.Marshal(record)
 	if err != nil {
-		return nil, fmt.Errorf("unable to marshal steering message with new value, skip message: %v", err)
+		zap.S().Errorf("unable to marshal debug record: %v", err)
+		return
 	}
-	return payload, nil
+	publish(c.client, c.debugTopic, &payload)
 }
 
+// Objects returns last objects received, none if objects are older than TTL
 func (c *Controller) Objects() []*events.Object {
 	c.muObjects.RLock()
 	defer c.muObjects.RUnlock()
-	res := make([]*events.Object, 0, len(c.objects))
-	for _, o := range c.objects {
+
+	if c.objectsTTL > 0 && len(c.objects.objects) > 0 {
+		age := c.objects.age(c.now())
+		if age > c.objectsTTL {
+			if !c.objectsStale.Swap(true) {
+				zap.S().Warnf("objects detection is stale, last objects are %v old, ignore them", age)
+			}
+			return []*events.Object{}
+		}
+	}
+
+	res := make([]*events.Object, 0, len(c.objects.objects))
+	for _, o := range c.objects.objects {
 		oCpy := o
 		res = append(res, oCpy)
 	}
-	zap.S().Debugf("copy object from %v to %v", c.objects, res)
+	zap.S().Debugf("copy object from %v to %v", c.objects.objects, res)
 	return res
 }
 
+/*
+objectsOfFrame returns objects to use to correct steering computed from frame. Without frame synchronisation, last
+objects are returned.
+*/
+func (c *Controller) objectsOfFrame(frame *events.FrameRef) []*events.Object {
+	if c.objectsBuffer == nil {
+		return c.Objects()
+	}
+	objects, ok := c.objectsBuffer.match(frame)
+	if !ok {
+		c.unmatchedFrames.Add(1)
+		c.metrics.UnmatchedFrame()
+		zap.S().Debugf("no objects found for frame %v/%v, skip correction", frame.GetName(), frame.GetId())
+		return []*events.Object{}
+	}
+	return objects
+}
+
+// UnmatchedFrames returns count of steering messages without objects from matching frame
+func (c *Controller) UnmatchedFrames() uint64 {
+	return c.unmatchedFrames.Load()
+}
+
+// objectsSnapshot stores objects with their timestamps
+type objectsSnapshot struct {
+	objects    []*events.Object
+	receivedAt time.Time
+	// createdAt is the creation time of the frame used to detect objects, zero if unknown
+	createdAt time.Time
+	frameId   string
+}
+
+// timestamp returns frame creation time if available, message reception time else
+func (s *objectsSnapshot) timestamp() time.Time {
+	if !s.createdAt.IsZero() {
+		return s.createdAt
+	}
+	return s.receivedAt
+}
+
+// age returns objects age, computed from frame creation if available or from message reception
+func (s *objectsSnapshot) age(now time.Time) time.Duration {
+	return now.Sub(s.timestamp())
+}
+
 var registerCallbacks = func(p *Controller) error {
 	err := service.RegisterCallback(p.client, p.driveModeTopic, p.onDriveMode)
 	if err != nil {
@@ -220,15 +852,45 @@ var registerCallbacks = func(p *Controller) error {
 		return err
 	}
 
-	err = service.RegisterCallback(p.client, p.tfSteeringTopic, p.onTFSteering)
-	if err != nil {
-		return err
+	if p.ensemble != nil {
+		for _, topic := range p.tfSteeringTopics {
+			err = service.RegisterCallback(p.client, topic, p.onEnsembleSteering(topic))
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = service.RegisterCallback(p.client, p.tfSteeringTopic, p.onTFSteering)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = service.RegisterCallback(p.client, p.objectsTopic, p.onObjects)
 	if err != nil {
 		return err
 	}
+
+	if p.throttleTopic != "" {
+		err = service.RegisterCallback(p.client, p.throttleTopic, p.onThrottle)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.speedZoneTopic != "" {
+		err = service.RegisterCallback(p.client, p.speedZoneTopic, p.onSpeedZone)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.roadTopic != "" {
+		err = service.RegisterCallback(p.client, p.roadTopic, p.onRoad)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
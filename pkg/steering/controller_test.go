@@ -1,10 +1,16 @@
 package steering
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"github.com/cyrilix/robocar-base/testtools"
 	"github.com/cyrilix/robocar-protobuf/go/events"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"math"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -66,17 +72,18 @@ func TestDefaultSteering(t *testing.T) {
 			events.ObjectsMessage{},
 		},
 		{
+			// Model blended with default authority
 			events.DriveModeMessage{DriveMode: events.DriveMode_COPILOT},
 			events.SteeringMessage{Steering: 0.5, Confidence: 1.0},
 			events.SteeringMessage{Steering: 0.6, Confidence: 1.0},
-			events.SteeringMessage{Steering: 0.6, Confidence: 1.0},
+			events.SteeringMessage{Steering: 0.55, Confidence: 1.0},
 			events.ObjectsMessage{},
 		},
 		{
 			events.DriveModeMessage{DriveMode: events.DriveMode_COPILOT},
 			events.SteeringMessage{Steering: 0.4, Confidence: 1.0},
 			events.SteeringMessage{Steering: 0.7, Confidence: 1.0},
-			events.SteeringMessage{Steering: 0.7, Confidence: 1.0},
+			events.SteeringMessage{Steering: 0.55, Confidence: 1.0},
 			events.ObjectsMessage{},
 		},
 		{
@@ -242,8 +249,8 @@ func TestController_Start(t *testing.T) {
 				objects:    events.ObjectsMessage{Objects: []*events.Object{&objectOnMiddleNear}},
 			},
 			correctionOnObject: 0.5,
-			// Get rc value without correction
-			want: events.SteeringMessage{Steering: 0.4, Confidence: 1.0},
+			// Get rc value blended with tf value, without correction
+			want: events.SteeringMessage{Steering: 0.35, Confidence: 1.0},
 		},
 		{
 			name: "On pilot drive mode, correction enabled",
@@ -359,8 +366,13 @@ func TestController_Start(t *testing.T) {
 			go c.Start()
 			time.Sleep(1 * time.Millisecond)
 
-			// Publish events and wait generation of new steering message
-			waitPublish.Add(1)
+			// Publish events and wait generation of new steering message, both rc and tf steering are published on
+			// copilot drive mode
+			if tt.msgEvents.driveMode.GetDriveMode() == events.DriveMode_COPILOT {
+				waitPublish.Add(2)
+			} else {
+				waitPublish.Add(1)
+			}
 			c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf(driveModeTopic, &tt.msgEvents.driveMode))
 			c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf(rcSteeringTopic, &tt.msgEvents.rcSteering))
 			c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf(tfSteeringTopic, &tt.msgEvents.tfSteering))
@@ -376,10 +388,1081 @@ func TestController_Start(t *testing.T) {
 			}
 			muEventsPublished.Unlock()
 
-			if msg.GetSteering() != tt.want.GetSteering() {
+			if math.Abs(float64(msg.GetSteering()-tt.want.GetSteering())) > 1e-6 {
 				t.Errorf("bad msg value for mode %v: %v, wants %v", c.driveMode.String(), msg.GetSteering(), tt.want.GetSteering())
 			}
 
 		})
 	}
 }
+
+// publishedMessages records payloads published by controller, by topic
+type publishedMessages struct {
+	mu       sync.Mutex
+	payloads map[string][][]byte
+}
+
+// capturePublish records payloads published until the end of test, publish function is restored on test cleanup
+func capturePublish(t *testing.T) *publishedMessages {
+	t.Helper()
+	oldPublish := publish
+	t.Cleanup(func() {
+		publish = oldPublish
+	})
+
+	p := &publishedMessages{payloads: make(map[string][][]byte)}
+	publish = func(_ mqtt.Client, topic string, payload *[]byte) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.payloads[topic] = append(p.payloads[topic], *payload)
+	}
+	return p
+}
+
+// all returns payloads published on topic, from the oldest to the last
+func (p *publishedMessages) all(topic string) [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]byte(nil), p.payloads[topic]...)
+}
+
+// count returns count of payloads published on topic
+func (p *publishedMessages) count(topic string) int {
+	return len(p.all(topic))
+}
+
+// last returns last payload published on topic, nil if none
+func (p *publishedMessages) last(topic string) []byte {
+	payloads := p.all(topic)
+	if len(payloads) == 0 {
+		return nil
+	}
+	return payloads[len(payloads)-1]
+}
+
+// lastSteering returns last steering message published on topic, nil if none
+func (p *publishedMessages) lastSteering(t *testing.T, topic string) *events.SteeringMessage {
+	t.Helper()
+	payload := p.last(topic)
+	if payload == nil {
+		return nil
+	}
+	var msg events.SteeringMessage
+	if err := proto.Unmarshal(payload, &msg); err != nil {
+		t.Errorf("unable to unmarshall steering message: %v", err)
+	}
+	return &msg
+}
+
+// reset forgets published payloads
+func (p *publishedMessages) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.payloads = make(map[string][][]byte)
+}
+
+func TestController_RCSteeringPassthrough(t *testing.T) {
+	published := capturePublish(t)
+
+	steeringTopic := "topic/steering"
+	rcSteeringTopic := "topic/rcSteering"
+
+	first, err := proto.Marshal(&events.SteeringMessage{Steering: 0.1, Confidence: 1.})
+	if err != nil {
+		t.Fatalf("unable to marshal steering message: %v", err)
+	}
+	last, err := proto.Marshal(&events.SteeringMessage{Steering: 0.3, Confidence: 1.})
+	if err != nil {
+		t.Fatalf("unable to marshal steering message: %v", err)
+	}
+	corrected, err := proto.Marshal(&events.SteeringMessage{Steering: 0.5, Confidence: 1.})
+	if err != nil {
+		t.Fatalf("unable to marshal steering message: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+		payload []byte
+		want    []byte
+	}{
+		{
+			name: "none processor changes steering",
+			// Fields are encoded twice, a new encoding of message would differ
+			payload: append(append([]byte{}, first...), last...),
+			want:    append(append([]byte{}, first...), last...),
+		},
+		{
+			name:    "steering filters don't apply to radio command",
+			options: []Option{WithSteeringFilters(NewSlewRateLimiter(0.))},
+			payload: append(append([]byte{}, first...), last...),
+			want:    append(append([]byte{}, first...), last...),
+		},
+		{
+			name:    "invalid message",
+			payload: []byte{0xff, 0xff},
+			want:    []byte{0xff, 0xff},
+		},
+		{
+			name: "corrected steering",
+			options: []Option{
+				WithObjectsCorrectionEnabled(true, true),
+				WithCorrector(&StaticCorrector{delta: 0.5}),
+			},
+			payload: append(append([]byte{}, first...), last...),
+			want:    corrected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewController(nil, steeringTopic, "topic/driveMode", rcSteeringTopic, "topic/tfSteering", "topic/objects", tt.options...)
+			c.onRCSteering(nil, testtools.NewFakeMessage(rcSteeringTopic, tt.payload))
+
+			if got := published.last(steeringTopic); !bytes.Equal(got, tt.want) {
+				t.Errorf("bad published payload: %v, wants %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_ObjectsFilter(t *testing.T) {
+	published := capturePublish(t)
+
+	steeringTopic := "topic/steering"
+	driveModeTopic := "topic/driveMode"
+	rcSteeringTopic := "topic/rcSteering"
+	tfSteeringTopic := "topic/tfSteering"
+	objectsTopic := "topic/objects"
+
+	objectOnMiddleNearLowConfidence := events.Object{
+		Type:       events.TypeObject_ANY,
+		Left:       0.4,
+		Top:        0.8,
+		Right:      0.6,
+		Bottom:     0.9,
+		Confidence: 0.1,
+	}
+
+	objectOnMiddleNearHalfConfidence := events.Object{
+		Type:       events.TypeObject_ANY,
+		Left:       0.4,
+		Top:        0.8,
+		Right:      0.6,
+		Bottom:     0.9,
+		Confidence: 0.6,
+	}
+
+	tests := []struct {
+		name        string
+		weighting   bool
+		objects     []*events.Object
+		wantObjects int
+		want        float32
+	}{
+		{
+			name:        "low confidence object is ignored",
+			objects:     []*events.Object{&objectOnMiddleNearLowConfidence},
+			wantObjects: 0,
+			want:        0.,
+		},
+		{
+			name:        "only confident object is kept",
+			objects:     []*events.Object{&objectOnMiddleNearLowConfidence, &objectOnMiddleNear},
+			wantObjects: 1,
+			want:        1.,
+		},
+		{
+			name:        "correction of kept object is weighted by its confidence",
+			weighting:   true,
+			objects:     []*events.Object{&objectOnMiddleNearLowConfidence, &objectOnMiddleNearHalfConfidence},
+			wantObjects: 1,
+			want:        0.6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewController(nil,
+				steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, objectsTopic,
+				WithObjectsCorrectionEnabled(true, false),
+				WithObjectsFilter(NewConfidenceFilter(0.5, tt.weighting)),
+			)
+
+			c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf(driveModeTopic, &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+			c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic, &events.ObjectsMessage{Objects: tt.objects}))
+			c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf(tfSteeringTopic, &events.SteeringMessage{Steering: 0., Confidence: 1.0}))
+
+			if got := len(c.Objects()); got != tt.wantObjects {
+				t.Errorf("bad objects count: %v, wants %v", got, tt.wantObjects)
+			}
+
+			msg := published.lastSteering(t, steeringTopic)
+			if msg.GetSteering() != tt.want {
+				t.Errorf("bad steering value: %v, wants %v", msg.GetSteering(), tt.want)
+			}
+		})
+	}
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Add(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestController_ObjectsTTL(t *testing.T) {
+	objectsTopic := "topic/objects"
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		frameRef    *events.FrameRef
+		elapsed     time.Duration
+		wantObjects int
+	}{
+		{
+			name:        "without ttl, objects are kept forever",
+			ttl:         0,
+			elapsed:     time.Hour,
+			wantObjects: 1,
+		},
+		{
+			name:        "recent objects",
+			ttl:         200 * time.Millisecond,
+			elapsed:     100 * time.Millisecond,
+			wantObjects: 1,
+		},
+		{
+			name:        "stale objects",
+			ttl:         200 * time.Millisecond,
+			elapsed:     300 * time.Millisecond,
+			wantObjects: 0,
+		},
+		{
+			name:        "stale objects according frame creation",
+			ttl:         200 * time.Millisecond,
+			frameRef:    &events.FrameRef{Name: "frame", Id: "01", CreatedAt: timestamppb.New(start.Add(-150 * time.Millisecond))},
+			elapsed:     100 * time.Millisecond,
+			wantObjects: 0,
+		},
+		{
+			name:        "recent objects according frame creation",
+			ttl:         200 * time.Millisecond,
+			frameRef:    &events.FrameRef{Name: "frame", Id: "01", CreatedAt: timestamppb.New(start.Add(-50 * time.Millisecond))},
+			elapsed:     100 * time.Millisecond,
+			wantObjects: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := fakeClock{now: start}
+			c := NewController(nil,
+				"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", objectsTopic,
+				WithObjectsTTL(tt.ttl),
+				WithClock(clock.Now),
+			)
+
+			c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic,
+				&events.ObjectsMessage{Objects: []*events.Object{&objectOnMiddleNear}, FrameRef: tt.frameRef}))
+			clock.Add(tt.elapsed)
+
+			if got := len(c.Objects()); got != tt.wantObjects {
+				t.Errorf("bad objects count: %v, wants %v", got, tt.wantObjects)
+			}
+
+			// New objects must reset staleness
+			c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic,
+				&events.ObjectsMessage{Objects: []*events.Object{&objectOnMiddleNear}}))
+			if got := len(c.Objects()); got != 1 {
+				t.Errorf("bad objects count after new message: %v, wants %v", got, 1)
+			}
+		})
+	}
+}
+
+func TestController_FrameSync(t *testing.T) {
+	published := capturePublish(t)
+
+	steeringTopic := "topic/steering"
+	driveModeTopic := "topic/driveMode"
+	tfSteeringTopic := "topic/tfSteering"
+	objectsTopic := "topic/objects"
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	frameSync, err := WithFrameSync(10, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unable to configure frame sync: %v", err)
+	}
+	metrics := NewMetrics()
+	c := NewController(nil,
+		steeringTopic, driveModeTopic, "topic/rcSteering", tfSteeringTopic, objectsTopic,
+		WithObjectsCorrectionEnabled(true, false),
+		WithCorrector(NewGridCorrector()),
+		WithMetrics(metrics),
+		frameSync,
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf(driveModeTopic, &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+
+	// Object near on frame 01, none on frame 02
+	c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic, &events.ObjectsMessage{
+		Objects:  []*events.Object{&objectOnMiddleNear},
+		FrameRef: &events.FrameRef{Name: "frame", Id: "01", CreatedAt: timestamppb.New(start)},
+	}))
+	c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic, &events.ObjectsMessage{
+		Objects:  []*events.Object{},
+		FrameRef: &events.FrameRef{Name: "frame", Id: "02", CreatedAt: timestamppb.New(start.Add(50 * time.Millisecond))},
+	}))
+
+	tests := []struct {
+		name          string
+		frame         *events.FrameRef
+		want          float32
+		wantUnmatched uint64
+	}{
+		{
+			name:          "objects of the same frame",
+			frame:         &events.FrameRef{Name: "frame", Id: "01", CreatedAt: timestamppb.New(start)},
+			want:          1.,
+			wantUnmatched: 0,
+		},
+		{
+			name:          "objects of the closest earlier frame",
+			frame:         &events.FrameRef{Name: "frame", Id: "03", CreatedAt: timestamppb.New(start.Add(60 * time.Millisecond))},
+			want:          0.,
+			wantUnmatched: 0,
+		},
+		{
+			name:          "unmatched frame",
+			frame:         &events.FrameRef{Name: "frame", Id: "04", CreatedAt: timestamppb.New(start.Add(time.Second))},
+			want:          0.,
+			wantUnmatched: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf(tfSteeringTopic,
+				&events.SteeringMessage{Steering: 0., Confidence: 1.0, FrameRef: tt.frame}))
+
+			msg := published.lastSteering(t, steeringTopic)
+			if msg.GetSteering() != tt.want {
+				t.Errorf("bad steering value: %v, wants %v", msg.GetSteering(), tt.want)
+			}
+			if msg.GetFrameRef().GetId() != tt.frame.GetId() {
+				t.Errorf("bad frame ref: %v, wants %v", msg.GetFrameRef(), tt.frame)
+			}
+			if c.UnmatchedFrames() != tt.wantUnmatched {
+				t.Errorf("bad unmatched frames count: %v, wants %v", c.UnmatchedFrames(), tt.wantUnmatched)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t.Fatalf("unable to write metrics: %v", err)
+	}
+	if got := parseMetrics(t, &buf)["steering_unmatched_frames_total"]; got != "1" {
+		t.Errorf("bad unmatched frames metric: %v, wants 1", got)
+	}
+}
+
+func TestController_Tracker(t *testing.T) {
+	objectsTopic := "topic/objects"
+	clock := fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", objectsTopic,
+		WithTracker(NewTracker(WithMinHits(2))),
+		WithClock(clock.Now),
+	)
+
+	for i, want := range []int{0, 1, 1} {
+		c.onObjects(nil, testtools.NewFakeMessageFromProtobuf(objectsTopic,
+			&events.ObjectsMessage{Objects: []*events.Object{&objectOnMiddleNear}}))
+		clock.Add(100 * time.Millisecond)
+		if got := len(c.Objects()); got != want {
+			t.Errorf("message %d: bad objects count: %v, wants %v", i, got, want)
+		}
+	}
+}
+
+func TestController_SteeringFilters(t *testing.T) {
+	published := capturePublish(t)
+
+	steeringTopic := "topic/steering"
+	driveModeTopic := "topic/driveMode"
+	rcSteeringTopic := "topic/rcSteering"
+	tfSteeringTopic := "topic/tfSteering"
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	c := NewController(nil,
+		steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, "topic/objects",
+		WithSteeringFilters(NewSlewRateLimiter(2.)),
+		WithClock(clock.Now),
+	)
+
+	sequence := []struct {
+		offset    time.Duration
+		driveMode events.DriveMode
+		steering  float32
+		want      float32
+	}{
+		// Radio command steering isn't smoothed
+		{offset: 0, driveMode: events.DriveMode_USER, steering: 1., want: 1.},
+		{offset: 0, driveMode: events.DriveMode_PILOT, steering: -1., want: -1.},
+		{offset: 100 * time.Millisecond, driveMode: events.DriveMode_PILOT, steering: 1., want: -0.8},
+		{offset: 200 * time.Millisecond, driveMode: events.DriveMode_PILOT, steering: 1., want: -0.6},
+		{offset: 250 * time.Millisecond, driveMode: events.DriveMode_PILOT, steering: -0.6, want: -0.6},
+	}
+	for i, s := range sequence {
+		clock.now = start.Add(s.offset)
+		c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf(driveModeTopic, &events.DriveModeMessage{DriveMode: s.driveMode}))
+		evt := &events.SteeringMessage{
+			Steering:   s.steering,
+			Confidence: 1.,
+			// Frame creation time isn't used by filters
+			FrameRef: &events.FrameRef{Name: "frame", Id: fmt.Sprintf("%02d", i), CreatedAt: timestamppb.New(start.Add(-time.Hour))},
+		}
+		if s.driveMode == events.DriveMode_USER {
+			c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf(rcSteeringTopic, evt))
+		} else {
+			c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf(tfSteeringTopic, evt))
+		}
+
+		msg := published.lastSteering(t, steeringTopic)
+		if math.Abs(float64(msg.GetSteering()-s.want)) > 1e-5 {
+			t.Errorf("step %d: bad steering value: %v, wants %v", i, msg.GetSteering(), s.want)
+		}
+	}
+}
+
+func TestController_WithProcessors(t *testing.T) {
+	published := capturePublish(t)
+
+	steeringTopic := "topic/steering"
+	driveModeTopic := "topic/driveMode"
+	rcSteeringTopic := "topic/rcSteering"
+	tfSteeringTopic := "topic/tfSteering"
+
+	var sources []Source
+	c := NewController(nil,
+		steeringTopic, driveModeTopic, rcSteeringTopic, tfSteeringTopic, "topic/objects",
+		// Ignored because processors are defined
+		WithObjectsCorrectionEnabled(true, true),
+		WithCorrector(&StaticCorrector{delta: 0.9}),
+		WithProcessors(
+			ProcessorFunc(func(s *Steering) {
+				sources = append(sources, s.Source)
+				s.Value = s.Value * 2
+			}),
+			ProcessorFunc(func(s *Steering) {
+				s.Value = s.Value + 0.1
+				s.Confidence = 0.5
+			}),
+		),
+	)
+
+	tests := []struct {
+		name      string
+		driveMode events.DriveMode
+		onMessage func(mqtt.Client, mqtt.Message)
+		topic     string
+		want      *events.SteeringMessage
+	}{
+		{
+			name:      "rc steering",
+			driveMode: events.DriveMode_USER,
+			onMessage: c.onRCSteering,
+			topic:     rcSteeringTopic,
+			want:      &events.SteeringMessage{Steering: 0.5, Confidence: 0.5},
+		},
+		{
+			name:      "tf steering",
+			driveMode: events.DriveMode_PILOT,
+			onMessage: c.onTFSteering,
+			topic:     tfSteeringTopic,
+			want:      &events.SteeringMessage{Steering: 0.5, Confidence: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf(driveModeTopic, &events.DriveModeMessage{DriveMode: tt.driveMode}))
+			tt.onMessage(nil, testtools.NewFakeMessageFromProtobuf(tt.topic, &events.SteeringMessage{Steering: 0.2, Confidence: 1.}))
+
+			msg := published.lastSteering(t, steeringTopic)
+			if math.Abs(float64(msg.GetSteering()-tt.want.GetSteering())) > 1e-6 || msg.GetConfidence() != tt.want.GetConfidence() {
+				t.Errorf("bad steering message: %v, wants %v", msg.String(), tt.want.String())
+			}
+		})
+	}
+	if !reflect.DeepEqual(sources, []Source{SourceRC, SourceTF}) {
+		t.Errorf("bad sources: %v, wants %v", sources, []Source{SourceRC, SourceTF})
+	}
+}
+
+func TestController_Calibration(t *testing.T) {
+	published := capturePublish(t)
+
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithProcessors(ProcessorFunc(func(s *Steering) {
+			s.Value = s.Value * 2
+		})),
+		WithCalibration(&Calibration{Trim: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 0.8}),
+	)
+
+	tests := []struct {
+		name     string
+		steering float32
+		want     float32
+	}{
+		{name: "calibration after pipeline", steering: 0.2, want: 0.5},
+		{name: "saturation", steering: 0.5, want: 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/rcSteering", &events.SteeringMessage{Steering: tt.steering, Confidence: 1.}))
+
+			msg := published.lastSteering(t, "topic/steering")
+			if math.Abs(float64(msg.GetSteering()-tt.want)) > 1e-6 {
+				t.Errorf("bad steering: %v, wants %v", msg.GetSteering(), tt.want)
+			}
+		})
+	}
+}
+
+func TestController_DeclaredCalibration(t *testing.T) {
+	published := capturePublish(t)
+
+	declared := &Calibration{Trim: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithProcessors(
+			declared,
+			ProcessorFunc(func(s *Steering) {
+				s.Value = s.Value * 2
+			}),
+		),
+		WithCalibration(&Calibration{Trim: 0.3, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}),
+	)
+
+	if len(c.processors) != 2 {
+		t.Errorf("bad processors count: %v, wants %v", len(c.processors), 2)
+	}
+	if c.calibration != declared {
+		t.Errorf("bad calibration: %v, wants declared one %v", c.calibration, declared)
+	}
+
+	c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/rcSteering", &events.SteeringMessage{Steering: 0.2, Confidence: 1.}))
+	msg := published.lastSteering(t, "topic/steering")
+	if math.Abs(float64(msg.GetSteering())-0.6) > 1e-6 {
+		t.Errorf("bad steering: %v, wants calibration at declared position %v", msg.GetSteering(), 0.6)
+	}
+}
+
+func TestController_Speed(t *testing.T) {
+	capturePublish(t)
+
+	var got Speed
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithThrottleTopic("topic/throttle"),
+		WithSpeedZoneTopic("topic/speedZone"),
+		WithProcessors(ProcessorFunc(func(s *Steering) {
+			got = s.Speed
+		})),
+	)
+
+	c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/rcSteering", &events.SteeringMessage{Steering: 0.2}))
+	if got != (Speed{}) {
+		t.Errorf("bad initial speed: %v, wants %v", got, Speed{})
+	}
+
+	c.onThrottle(nil, testtools.NewFakeMessageFromProtobuf("topic/throttle", &events.ThrottleMessage{Throttle: 0.5}))
+	c.onSpeedZone(nil, testtools.NewFakeMessageFromProtobuf("topic/speedZone", &events.SpeedZoneMessage{SpeedZone: events.SpeedZone_FAST}))
+	c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/rcSteering", &events.SteeringMessage{Steering: 0.2}))
+
+	want := Speed{Throttle: 0.5, Zone: events.SpeedZone_FAST}
+	if got != want {
+		t.Errorf("bad speed: %v, wants %v", got, want)
+	}
+	if c.Speed() != want {
+		t.Errorf("Speed() = %v, wants %v", c.Speed(), want)
+	}
+}
+
+func TestController_RoadCorrector(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithRoadTopic("topic/road"),
+		WithRoadCorrector(NewRoadCorrector(WithRoadWeight(0.5))),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{Steering: 0.1, Confidence: 1.}))
+	msg := published.lastSteering(t, "topic/steering")
+	if math.Abs(float64(msg.GetSteering())-0.1) > 1e-6 {
+		t.Errorf("bad steering without road: %v, wants %v", msg.GetSteering(), 0.1)
+	}
+
+	c.onRoad(nil, testtools.NewFakeMessageFromProtobuf("topic/road", roadMessage(120, 20, 100, 0., 1.)))
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{Steering: 0.1, Confidence: 1.}))
+	msg = published.lastSteering(t, "topic/steering")
+	if math.Abs(float64(msg.GetSteering())-0.3) > 1e-6 {
+		t.Errorf("bad steering with road: %v, wants %v", msg.GetSteering(), 0.3)
+	}
+}
+
+func TestController_Arbiter(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithArbiter(NewArbiter(0.5, WithFallback(FallbackStraight))),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+
+	tests := []struct {
+		name string
+		msg  *events.SteeringMessage
+		want *events.SteeringMessage
+	}{
+		{name: "confident model", msg: &events.SteeringMessage{Steering: 0.4, Confidence: 0.9}, want: &events.SteeringMessage{Steering: 0.4, Confidence: 0.9}},
+		{name: "low confidence", msg: &events.SteeringMessage{Steering: 0.4, Confidence: 0.3}, want: &events.SteeringMessage{Steering: 0., Confidence: 0.}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", tt.msg))
+			msg := published.lastSteering(t, "topic/steering")
+			if msg.GetSteering() != tt.want.GetSteering() || msg.GetConfidence() != tt.want.GetConfidence() {
+				t.Errorf("bad steering message: %v, wants %v", msg.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+func TestController_Copilot(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithCopilot(NewCopilot(
+			WithAuthority(0.5),
+			WithOverrideThreshold(0.5),
+			WithAuthorityRecovery(time.Second),
+			WithModelTTL(0),
+		)),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_COPILOT}))
+
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		onMessage func(mqtt.Client, mqtt.Message)
+		steering  float32
+		want      float32
+	}{
+		{name: "human drives", onMessage: c.onRCSteering, steering: 0.2, want: 0.2},
+		{name: "model blended", delay: 20 * time.Millisecond, onMessage: c.onTFSteering, steering: 0.6, want: 0.4},
+		{name: "human input blended", delay: 20 * time.Millisecond, onMessage: c.onRCSteering, steering: 0.4, want: 0.5},
+		{name: "human overrides at once", delay: 20 * time.Millisecond, onMessage: c.onRCSteering, steering: -0.9, want: -0.9},
+		{name: "model ignored while overriding", delay: 20 * time.Millisecond, onMessage: c.onTFSteering, steering: 0.6, want: -0.9},
+		{name: "human releases", delay: 20 * time.Millisecond, onMessage: c.onRCSteering, steering: 0., want: 0.},
+		{name: "authority quarter handed back", delay: 500 * time.Millisecond, onMessage: c.onTFSteering, steering: 0.8, want: 0.2},
+		{name: "authority fully handed back", delay: 500 * time.Millisecond, onMessage: c.onRCSteering, steering: 0., want: 0.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Add(tt.delay)
+			tt.onMessage(nil, testtools.NewFakeMessageFromProtobuf("topic/steering", &events.SteeringMessage{Steering: tt.steering, Confidence: 1.}))
+
+			msg := published.lastSteering(t, "topic/steering")
+			if math.Abs(float64(msg.GetSteering()-tt.want)) > 1e-6 {
+				t.Errorf("bad steering: %v, wants %v", msg.GetSteering(), tt.want)
+			}
+			if msg.GetConfidence() != 1. {
+				t.Errorf("bad confidence: %v, wants %v", msg.GetConfidence(), 1.)
+			}
+		})
+	}
+}
+
+func TestController_Ensemble(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "", "topic/objects",
+		WithClock(clock.Now),
+		WithEnsemble([]string{"topic/tf/a", "topic/tf/b"}, WithFusion(FusionWeighted), WithEnsembleWindow(time.Hour)),
+		WithProcessors(ProcessorFunc(func(s *Steering) {
+			s.Value += 0.1
+		})),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	onA, onB := c.onEnsembleSteering("topic/tf/a"), c.onEnsembleSteering("topic/tf/b")
+
+	lastPublished := func() (int, *events.SteeringMessage) {
+		return published.count("topic/steering"), published.lastSteering(t, "topic/steering")
+	}
+
+	onA(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/a", steeringOfFrame("1", 0.2, 1.)))
+	if count, _ := lastPublished(); count != 0 {
+		t.Errorf("steering published before all models: %v messages", count)
+	}
+
+	onB(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/b", steeringOfFrame("1", 0.6, 1.)))
+	count, msg := lastPublished()
+	if count != 1 {
+		t.Fatalf("bad published messages count: %v, wants %v", count, 1)
+	}
+	// Fused steering goes through pipeline
+	if math.Abs(float64(msg.GetSteering())-0.5) > 1e-6 || msg.GetFrameRef().GetId() != "1" {
+		t.Errorf("bad fused steering message: %v", msg)
+	}
+
+	// Frame with a missing model is published at the end of window
+	onB(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/b", steeringOfFrame("2", -0.4, 1.)))
+	onB(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/b", steeringOfFrame("2", -0.4, 1.)))
+	c.muEnsembleTimers.Lock()
+	if len(c.ensembleTimers) != 1 {
+		t.Errorf("bad timers count for pending frame: %v, wants 1", len(c.ensembleTimers))
+	}
+	c.muEnsembleTimers.Unlock()
+	c.flushEnsemble()
+	if count, _ := lastPublished(); count != 1 {
+		t.Errorf("steering published before end of window: %v messages", count)
+	}
+	clock.Add(time.Hour)
+	c.flushEnsemble()
+	count, msg = lastPublished()
+	if count != 2 {
+		t.Fatalf("bad published messages count: %v, wants %v", count, 2)
+	}
+	if math.Abs(float64(msg.GetSteering())-(-0.3)) > 1e-6 || msg.GetFrameRef().GetId() != "2" {
+		t.Errorf("bad partially fused steering message: %v", msg)
+	}
+	c.muEnsembleTimers.Lock()
+	if len(c.ensembleTimers) != 0 {
+		t.Errorf("timers of published frames aren't stopped: %v", c.ensembleTimers)
+	}
+	c.muEnsembleTimers.Unlock()
+
+	// Steering without frame can't be fused, it is published at once
+	onA(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/a", &events.SteeringMessage{Steering: 0.2, Confidence: 1.}))
+	count, msg = lastPublished()
+	if count != 3 {
+		t.Fatalf("bad published messages count: %v, wants %v", count, 3)
+	}
+	if math.Abs(float64(msg.GetSteering())-0.3) > 1e-6 {
+		t.Errorf("bad steering message without frame: %v", msg)
+	}
+}
+
+func TestController_Watchdog(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithWatchdog(NewWatchdog(100*time.Millisecond, WithSafeSteering(SafeDecay), WithSafeDecay(time.Second)), "topic/failsafe"),
+		WithCalibration(&Calibration{Trim: 0.1, LeftGain: 1, RightGain: 1, Min: -1, Max: 1}),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{Steering: 0.5, Confidence: 1.}))
+
+	clock.Add(50 * time.Millisecond)
+	c.checkWatchdog()
+	if c.Fault() || published.count("topic/steering") != 1 {
+		t.Errorf("watchdog raised before timeout")
+	}
+
+	// tflite part stops
+	clock.Add(100 * time.Millisecond)
+	c.checkWatchdog()
+	if !c.Fault() {
+		t.Errorf("fault not raised")
+	}
+	if published.count("topic/failsafe") != 1 {
+		t.Fatalf("bad failsafe alerts count: %v, wants %v", published.count("topic/failsafe"), 1)
+	}
+	var alert events.DriveModeMessage
+	if err := proto.Unmarshal(published.all("topic/failsafe")[0], &alert); err != nil || alert.GetDriveMode() != events.DriveMode_USER {
+		t.Errorf("bad failsafe alert: %v, %v", alert.String(), err)
+	}
+	if msg := published.lastSteering(t, "topic/steering"); math.Abs(float64(msg.GetSteering())-0.6) > 1e-6 || msg.GetConfidence() != 0. {
+		t.Errorf("bad safe steering: %v, wants decay from last published value 0.6", msg.String())
+	}
+
+	clock.Add(500 * time.Millisecond)
+	c.checkWatchdog()
+	if msg := published.lastSteering(t, "topic/steering"); math.Abs(float64(msg.GetSteering())-0.35) > 1e-6 {
+		t.Errorf("bad safe steering: %v, wants decay to trim %v", msg.GetSteering(), 0.35)
+	}
+	clock.Add(time.Second)
+	c.checkWatchdog()
+	if msg := published.lastSteering(t, "topic/steering"); math.Abs(float64(msg.GetSteering())-0.1) > 1e-6 {
+		t.Errorf("bad safe steering: %v, wants trim %v", msg.GetSteering(), 0.1)
+	}
+	if published.count("topic/failsafe") != 1 {
+		t.Errorf("failsafe alert published several times: %v", published.count("topic/failsafe"))
+	}
+
+	// tflite part restarts
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{Steering: 0.2, Confidence: 1.}))
+	if c.Fault() {
+		t.Errorf("fault not cleared")
+	}
+	clock.Add(50 * time.Millisecond)
+	count := published.count("topic/steering")
+	c.checkWatchdog()
+	if published.count("topic/steering") != count {
+		t.Errorf("safe steering published after recovery")
+	}
+
+	// Drive mode change arms watchdog for new source
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_USER}))
+	clock.Add(80 * time.Millisecond)
+	c.checkWatchdog()
+	if c.Fault() {
+		t.Errorf("fault raised before timeout of new source")
+	}
+	clock.Add(80 * time.Millisecond)
+	c.checkWatchdog()
+	if !c.Fault() || published.count("topic/failsafe") != 2 {
+		t.Errorf("fault not raised for radio command")
+	}
+}
+
+func TestController_DriveModeTransition(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithTransition(500*time.Millisecond),
+	)
+
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		driveMode events.DriveMode
+		onMessage func(mqtt.Client, mqtt.Message)
+		steering  float32
+		want      float32
+	}{
+		{name: "user drives", driveMode: events.DriveMode_USER, onMessage: c.onRCSteering, steering: 0.8, want: 0.8},
+		{name: "switch to pilot starts from last published", delay: 10 * time.Millisecond, driveMode: events.DriveMode_PILOT, onMessage: c.onTFSteering, steering: -0.8, want: 0.8},
+		{name: "ramp to pilot", delay: 250 * time.Millisecond, driveMode: events.DriveMode_PILOT, onMessage: c.onTFSteering, steering: -0.8, want: 0.},
+		{name: "pilot reached", delay: 250 * time.Millisecond, driveMode: events.DriveMode_PILOT, onMessage: c.onTFSteering, steering: -0.8, want: -0.8},
+		{name: "invalid drive mode rejected", delay: 10 * time.Millisecond, driveMode: events.DriveMode_INVALID, onMessage: c.onTFSteering, steering: -0.6, want: -0.6},
+		{name: "switch back to user", delay: 10 * time.Millisecond, driveMode: events.DriveMode_USER, onMessage: c.onRCSteering, steering: 0., want: -0.6},
+		{name: "ramp to user", delay: 125 * time.Millisecond, driveMode: events.DriveMode_USER, onMessage: c.onRCSteering, steering: 0., want: -0.45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Add(tt.delay)
+			c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: tt.driveMode}))
+			published.reset()
+			tt.onMessage(nil, testtools.NewFakeMessageFromProtobuf("topic/steering", &events.SteeringMessage{Steering: tt.steering, Confidence: 1.}))
+
+			msg := published.lastSteering(t, "topic/steering")
+			if msg == nil {
+				t.Fatalf("no steering published on %v drive mode", c.driveMode)
+			}
+			if math.Abs(float64(msg.GetSteering()-tt.want)) > 1e-6 {
+				t.Errorf("bad steering: %v, wants %v", msg.GetSteering(), tt.want)
+			}
+		})
+	}
+	if c.driveMode != events.DriveMode_USER {
+		t.Errorf("bad drive mode: %v, wants %v", c.driveMode, events.DriveMode_USER)
+	}
+}
+
+func TestController_Metrics(t *testing.T) {
+	capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	metrics := NewMetrics()
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithCorrector(&StaticCorrector{delta: 0.3}),
+		WithObjectsCorrectionEnabled(true, false),
+		WithMetrics(metrics),
+	)
+
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	c.onObjects(nil, testtools.NewFakeMessageFromProtobuf("topic/objects", &events.ObjectsMessage{Objects: []*events.Object{
+		{Type: events.TypeObject_ANY, Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9},
+	}}))
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{Steering: 0.1, Confidence: 1.}))
+	c.onRCSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/rcSteering", &events.SteeringMessage{Steering: 0.5, Confidence: 1.}))
+	c.onTFSteering(nil, testtools.NewFakeMessage("topic/tfSteering", []byte("invalid")))
+
+	var buf bytes.Buffer
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t.Fatalf("unable to write metrics: %v", err)
+	}
+	samples := parseMetrics(t, &buf)
+
+	tests := []struct {
+		sample string
+		want   string
+	}{
+		{sample: `steering_messages_received_total{topic="topic/driveMode"}`, want: "1"},
+		{sample: `steering_messages_received_total{topic="topic/objects"}`, want: "1"},
+		{sample: `steering_messages_received_total{topic="topic/tfSteering"}`, want: "2"},
+		{sample: `steering_messages_received_total{topic="topic/rcSteering"}`, want: "1"},
+		{sample: `steering_unmarshal_errors_total{topic="topic/tfSteering"}`, want: "1"},
+		{sample: `steering_corrections_total`, want: "1"},
+		{sample: `steering_correction_size_bucket{le="0.2"}`, want: "1"},
+		{sample: `steering_publish_latency_seconds_count`, want: "1"},
+		{sample: `steering_drive_mode{mode="PILOT"}`, want: "1"},
+		{sample: `steering_drive_mode{mode="USER"}`, want: "0"},
+		{sample: `steering_objects`, want: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sample, func(t *testing.T) {
+			if got := samples[tt.sample]; got != tt.want {
+				t.Errorf("bad value: %v, wants %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_Metrics_EnsembleLatency(t *testing.T) {
+	capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	metrics := NewMetrics()
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "", "topic/objects",
+		WithClock(clock.Now),
+		WithEnsemble([]string{"topic/tf/a", "topic/tf/b"}, WithEnsembleWindow(time.Hour)),
+		WithMetrics(metrics),
+	)
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	onA, onB := c.onEnsembleSteering("topic/tf/a"), c.onEnsembleSteering("topic/tf/b")
+
+	// Latency is measured from reception of first steering of frame, ensemble wait included
+	onA(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/a", steeringOfFrame("1", 0.2, 1.)))
+	clock.Add(30 * time.Millisecond)
+	onB(nil, testtools.NewFakeMessageFromProtobuf("topic/tf/b", steeringOfFrame("1", 0.6, 1.)))
+
+	var buf bytes.Buffer
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t.Fatalf("unable to write metrics: %v", err)
+	}
+	samples := parseMetrics(t, &buf)
+	for sample, want := range map[string]string{
+		`steering_publish_latency_seconds_bucket{le="0.025"}`: "0",
+		`steering_publish_latency_seconds_bucket{le="0.05"}`:  "1",
+	} {
+		if got := samples[sample]; got != want {
+			t.Errorf("bad value of %v: %v, wants %v", sample, got, want)
+		}
+	}
+}
+
+func TestController_DebugTopic(t *testing.T) {
+	published := capturePublish(t)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithClock(clock.Now),
+		WithObjectsCorrectionEnabled(true, false),
+		WithDebugTopic("topic/steering/debug"),
+	)
+
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	c.onObjects(nil, testtools.NewFakeMessageFromProtobuf("topic/objects", &events.ObjectsMessage{Objects: []*events.Object{
+		{Type: events.TypeObject_CAR, Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9},
+	}}))
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{
+		Steering: 0., Confidence: 1., FrameRef: &events.FrameRef{Name: "camera", Id: "frame-1"},
+	}))
+
+	payload := published.last("topic/steering/debug")
+	if payload == nil {
+		t.Fatalf("no debug record published")
+	}
+	var got DebugRecord
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("unable to unmarshal debug record: %v", err)
+	}
+	nearest := DebugObject{Type: "CAR", Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9}
+	want := DebugRecord{
+		Timestamp:  clock.Now(),
+		FrameId:    "frame-1",
+		Input:      0.,
+		Source:     SourceTF,
+		DriveMode:  "PILOT",
+		Objects:    []DebugObject{nearest},
+		Nearest:    &nearest,
+		GridCell:   &GridCell{Row: 4, Col: 2},
+		Deviation:  1.,
+		Correction: 1.,
+		Value:      1.,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad debug record: %+v, want %+v", got, want)
+	}
+	if published.count("topic/steering") == 0 {
+		t.Errorf("no steering published")
+	}
+}
+
+func TestController_DebugTopic_WithoutCorrection(t *testing.T) {
+	published := capturePublish(t)
+
+	frameSync, err := WithFrameSync(10, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unable to configure frame sync: %v", err)
+	}
+	c := NewController(nil,
+		"topic/steering", "topic/driveMode", "topic/rcSteering", "topic/tfSteering", "topic/objects",
+		WithObjectsCorrectionEnabled(false, false),
+		WithDebugTopic("topic/steering/debug"),
+		frameSync,
+	)
+
+	c.onDriveMode(nil, testtools.NewFakeMessageFromProtobuf("topic/driveMode", &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT}))
+	c.onTFSteering(nil, testtools.NewFakeMessageFromProtobuf("topic/tfSteering", &events.SteeringMessage{
+		Steering: 0.2, Confidence: 1., FrameRef: &events.FrameRef{Name: "camera", Id: "frame-1"},
+	}))
+
+	var got DebugRecord
+	if err := json.Unmarshal(published.last("topic/steering/debug"), &got); err != nil {
+		t.Fatalf("unable to unmarshal debug record: %v", err)
+	}
+	if len(got.Objects) != 0 {
+		t.Errorf("bad debug objects: %v, wants none", got.Objects)
+	}
+	// Debug record must not search objects of frame for correction
+	if c.UnmatchedFrames() != 0 {
+		t.Errorf("bad unmatched frames count: %v, wants 0", c.UnmatchedFrames())
+	}
+}
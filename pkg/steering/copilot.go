@@ -0,0 +1,139 @@
+package steering
+
+import (
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+type OptionCopilot func(c *Copilot)
+
+// WithAuthority defines weight of model steering blended into radio command steering, between 0 and 1
+func WithAuthority(authority float64) OptionCopilot {
+	return func(c *Copilot) {
+		c.authority = authority
+	}
+}
+
+// WithOverrideThreshold defines radio command steering magnitude above which human overrides model
+func WithOverrideThreshold(threshold float64) OptionCopilot {
+	return func(c *Copilot) {
+		c.overrideThreshold = threshold
+	}
+}
+
+// WithAuthorityRecovery defines delay to hand back authority to model after an override
+func WithAuthorityRecovery(d time.Duration) OptionCopilot {
+	return func(c *Copilot) {
+		c.recovery = d
+	}
+}
+
+// WithModelTTL defines delay after which model steering is ignored if no new value is received
+func WithModelTTL(ttl time.Duration) OptionCopilot {
+	return func(c *Copilot) {
+		c.modelTTL = ttl
+	}
+}
+
+func NewCopilot(options ...OptionCopilot) *Copilot {
+	c := &Copilot{
+		authority:         0.5,
+		overrideThreshold: 0.5,
+		recovery:          time.Second,
+		modelTTL:          500 * time.Millisecond,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+/*
+Copilot blends model steering into human radio command steering, for COPILOT drive mode.
+
+Output is (1 - a) * rc + a * model where a is the model authority. When absolute radio command steering is above
+override threshold, human takes control at once and authority is 0. Once radio command steering comes back below
+threshold, authority increases linearly from 0 to its configured value over recovery delay.
+
+Model steering older than model TTL is ignored.
+*/
+type Copilot struct {
+	authority         float64
+	overrideThreshold float64
+	recovery          time.Duration
+	modelTTL          time.Duration
+
+	mu              sync.Mutex
+	rc              float64
+	rcConfidence    float64
+	model           float64
+	modelConfidence float64
+	modelTime       time.Time
+	overriding      bool
+	// lastOverride is the release time of last override
+	lastOverride time.Time
+}
+
+// OnRC records human steering and returns blended steering and confidence
+func (c *Copilot) OnRC(steering, confidence float64, ts time.Time) (float64, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rc, c.rcConfidence = steering, confidence
+	if math.Abs(steering) > c.overrideThreshold {
+		if !c.overriding {
+			zap.S().Infof("copilot: radio command steering %v overrides model", steering)
+		}
+		c.overriding = true
+	} else if c.overriding {
+		zap.S().Infof("copilot: radio command released, hand back authority to model over %v", c.recovery)
+		c.overriding = false
+		c.lastOverride = ts
+	}
+	return c.blend(ts)
+}
+
+// OnModel records model steering and returns blended steering and confidence
+func (c *Copilot) OnModel(steering, confidence float64, ts time.Time) (float64, float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.model, c.modelConfidence, c.modelTime = steering, confidence, ts
+	return c.blend(ts)
+}
+
+// Authority returns weight of model steering at ts
+func (c *Copilot) Authority(ts time.Time) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authorityAt(ts)
+}
+
+// authorityAt returns weight of model steering at ts, lock must be held
+func (c *Copilot) authorityAt(ts time.Time) float64 {
+	if c.overriding || c.modelTime.IsZero() {
+		return 0.
+	}
+	if c.modelTTL > 0 && ts.Sub(c.modelTime) > c.modelTTL {
+		return 0.
+	}
+	if c.lastOverride.IsZero() || c.recovery <= 0 {
+		return c.authority
+	}
+	elapsed := ts.Sub(c.lastOverride)
+	if elapsed >= c.recovery {
+		return c.authority
+	}
+	return c.authority * math.Max(float64(elapsed), 0.) / float64(c.recovery)
+}
+
+// blend returns blended steering and confidence at ts, lock must be held
+func (c *Copilot) blend(ts time.Time) (float64, float64) {
+	a := c.authorityAt(ts)
+	value := (1.-a)*c.rc + a*c.model
+	confidence := (1.-a)*c.rcConfidence + a*c.modelConfidence
+	zap.S().Debugf("copilot: blend rc %v and model %v with authority %v: %v", c.rc, c.model, a, value)
+	return value, confidence
+}
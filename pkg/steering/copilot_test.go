@@ -0,0 +1,92 @@
+package steering
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCopilot(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type input struct {
+		offset     time.Duration
+		rc         bool
+		steering   float64
+		confidence float64
+		want       float64
+		wantConf   float64
+	}
+	tests := []struct {
+		name    string
+		options []OptionCopilot
+		inputs  []input
+	}{
+		{
+			name: "rc only",
+			inputs: []input{
+				{rc: true, steering: 0.3, confidence: 1., want: 0.3, wantConf: 1.},
+			},
+		},
+		{
+			name:    "blend with authority",
+			options: []OptionCopilot{WithAuthority(0.25)},
+			inputs: []input{
+				{rc: true, steering: 0.2, confidence: 1., want: 0.2, wantConf: 1.},
+				{offset: 10 * time.Millisecond, rc: false, steering: 0.6, confidence: 0.6, want: 0.3, wantConf: 0.9},
+				{offset: 20 * time.Millisecond, rc: true, steering: -0.2, confidence: 1., want: 0., wantConf: 0.9},
+			},
+		},
+		{
+			name:    "stale model",
+			options: []OptionCopilot{WithAuthority(0.5), WithModelTTL(100 * time.Millisecond)},
+			inputs: []input{
+				{rc: false, steering: 0.6, confidence: 1., want: 0.3, wantConf: 0.5},
+				{offset: 200 * time.Millisecond, rc: true, steering: 0.2, confidence: 1., want: 0.2, wantConf: 1.},
+			},
+		},
+		{
+			name:    "override and recovery",
+			options: []OptionCopilot{WithAuthority(0.5), WithOverrideThreshold(0.5), WithAuthorityRecovery(time.Second), WithModelTTL(0)},
+			inputs: []input{
+				{rc: false, steering: 0.4, confidence: 1., want: 0.2, wantConf: 0.5},
+				// Large rc input overrides at once
+				{offset: 100 * time.Millisecond, rc: true, steering: -0.8, confidence: 1., want: -0.8, wantConf: 1.},
+				{offset: 200 * time.Millisecond, rc: false, steering: 0.4, confidence: 1., want: -0.8, wantConf: 1.},
+				// Release, authority is handed back gradually
+				{offset: 300 * time.Millisecond, rc: true, steering: 0., confidence: 1., want: 0., wantConf: 1.},
+				{offset: 600 * time.Millisecond, rc: false, steering: 0.4, confidence: 1., want: 0.06, wantConf: 1.},
+				{offset: 1100 * time.Millisecond, rc: true, steering: 0., confidence: 1., want: 0.16, wantConf: 1.},
+				{offset: 1300 * time.Millisecond, rc: false, steering: 0.4, confidence: 1., want: 0.2, wantConf: 1.},
+				{offset: 2 * time.Second, rc: true, steering: 0., confidence: 1., want: 0.2, wantConf: 1.},
+			},
+		},
+		{
+			name:    "rc input below threshold doesn't override",
+			options: []OptionCopilot{WithAuthority(0.5), WithOverrideThreshold(0.5), WithModelTTL(0)},
+			inputs: []input{
+				{rc: false, steering: 0.4, confidence: 1., want: 0.2, wantConf: 0.5},
+				{offset: 100 * time.Millisecond, rc: true, steering: -0.5, confidence: 1., want: -0.05, wantConf: 1.},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCopilot(tt.options...)
+			for i, in := range tt.inputs {
+				var got, gotConf float64
+				if in.rc {
+					got, gotConf = c.OnRC(in.steering, in.confidence, start.Add(in.offset))
+				} else {
+					got, gotConf = c.OnModel(in.steering, in.confidence, start.Add(in.offset))
+				}
+				if math.Abs(got-in.want) > 1e-9 {
+					t.Errorf("input %d: steering = %v, want %v", i, got, in.want)
+				}
+				if math.Abs(gotConf-in.wantConf) > 1e-9 {
+					t.Errorf("input %d: confidence = %v, want %v", i, gotConf, in.wantConf)
+				}
+			}
+		})
+	}
+}
@@ -2,18 +2,37 @@ package steering
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/cyrilix/robocar-protobuf/go/events"
 	"go.uber.org/zap"
+	"math"
 	"os"
 )
 
 type Corrector interface {
 	AdjustFromObjectPosition(currentSteering float64, objects []*events.Object) float64
 }
+
+// Diagnosis explains correction applied by a corrector
+type Diagnosis struct {
+	// Nearest is the nearest object taken into account, nil if none
+	Nearest *events.Object
+	// Cell is the grid map cell used for nearest object, nil if not available
+	Cell *GridCell
+	// Deviation is the correction computed from objects, before gain and clamping
+	Deviation float64
+	// Clamped is true when corrected steering has been limited to [-1, 1]
+	Clamped bool
+}
+
+// DiagnosticCorrector is implemented by correctors able to explain their correction
+type DiagnosticCorrector interface {
+	Diagnose(currentSteering float64, objects []*events.Object) (float64, Diagnosis)
+}
 type OptionCorrector func(c *GridCorrector)
 
-func WithGridMap(configPath string) OptionCorrector {
+func WithGridMap(configPath string) (OptionCorrector, error) {
 	var gm *GridMap
 	if configPath == "" {
 		zap.S().Warnf("no configuration defined for grid map, use default")
@@ -22,15 +41,19 @@ func WithGridMap(configPath string) OptionCorrector {
 		var err error
 		gm, err = loadConfig(configPath)
 		if err != nil {
-			zap.S().Panicf("unable to load grid-map config from file '%v': %w", configPath, err)
+			return nil, fmt.Errorf("unable to load grid-map config from file '%v': %w", configPath, err)
 		}
 	}
 	return func(c *GridCorrector) {
+		if gm.DistanceUnit == DistanceMm {
+			c.metricGridMap = gm
+			return
+		}
 		c.gridMap = gm
-	}
+	}, nil
 }
 
-func WithObjectMoveFactors(configPath string) OptionCorrector {
+func WithObjectMoveFactors(configPath string) (OptionCorrector, error) {
 	var omf *GridMap
 	if configPath == "" {
 		zap.S().Warnf("no configuration defined for objects move factors, use default")
@@ -39,12 +62,16 @@ func WithObjectMoveFactors(configPath string) OptionCorrector {
 		var err error
 		omf, err = loadConfig(configPath)
 		if err != nil {
-			zap.S().Panicf("unable to load objects move factors config from file '%v': %w", configPath, err)
+			return nil, fmt.Errorf("unable to load objects move factors config from file '%v': %w", configPath, err)
 		}
 	}
 	return func(c *GridCorrector) {
+		if omf.DistanceUnit == DistanceMm {
+			c.metricObjectMoveFactors = omf
+			return
+		}
 		c.objectMoveFactors = omf
-	}
+	}, nil
 }
 
 func loadConfig(configPath string) (*GridMap, error) {
@@ -57,6 +84,9 @@ func loadConfig(configPath string) (*GridMap, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to unmarshal json config '%s': %w", configPath, err)
 	}
+	if err := gm.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid json config '%s': %w", configPath, err)
+	}
 	return &gm, nil
 }
 
@@ -66,22 +96,124 @@ func WidthDeltaMiddle(d float64) OptionCorrector {
 	}
 }
 
+// ObjectsStrategy defines how deviations of several objects are combined
+type ObjectsStrategy string
+
+const (
+	// StrategyMaxMagnitude applies the strongest deviation
+	StrategyMaxMagnitude ObjectsStrategy = "max"
+	// StrategyWeightedSum averages deviations weighted by object proximity
+	StrategyWeightedSum ObjectsStrategy = "weighted"
+	// StrategyFreeGap steers to the centre of the widest free gap between objects
+	StrategyFreeGap ObjectsStrategy = "gap"
+)
+
+func WithObjectsStrategy(s ObjectsStrategy) OptionCorrector {
+	return func(c *GridCorrector) {
+		c.objectsStrategy = s
+	}
+}
+
+// WithDistanceSource defines how distance of objects is computed, DepthDistance by default
+func WithDistanceSource(s DistanceSource) OptionCorrector {
+	return func(c *GridCorrector) {
+		c.distanceSource = s
+	}
+}
+
+// WithInterpolation overrides interpolation mode of grid map and objects move factors
+func WithInterpolation(i Interpolation) OptionCorrector {
+	return func(c *GridCorrector) {
+		c.interpolation = i
+	}
+}
+
 func NewGridCorrector(options ...OptionCorrector) *GridCorrector {
 	c := &GridCorrector{
 		gridMap:           &defaultGridMap,
 		objectMoveFactors: &defaultObjectFactors,
 		deltaMiddle:       0.1,
+		objectsStrategy:   StrategyMaxMagnitude,
+		distanceSource:    DepthDistance{},
 	}
 	for _, o := range options {
 		o(c)
 	}
+	if c.interpolation != "" {
+		c.gridMap = c.gridMap.WithInterpolation(c.interpolation)
+		c.objectMoveFactors = c.objectMoveFactors.WithInterpolation(c.interpolation)
+		if c.metricGridMap != nil {
+			c.metricGridMap = c.metricGridMap.WithInterpolation(c.interpolation)
+		}
+		if c.metricObjectMoveFactors != nil {
+			c.metricObjectMoveFactors = c.metricObjectMoveFactors.WithInterpolation(c.interpolation)
+		}
+		c.profiles = c.profiles.withInterpolation(c.interpolation)
+	}
 	return c
 }
 
 type GridCorrector struct {
 	gridMap           *GridMap
 	objectMoveFactors *GridMap
-	deltaMiddle       float64
+	// Optional grid maps with rows in millimeters, used when metric distance of object is available
+	metricGridMap           *GridMap
+	metricObjectMoveFactors *GridMap
+	distanceSource          DistanceSource
+	deltaMiddle             float64
+	interpolation           Interpolation
+	objectsStrategy         ObjectsStrategy
+	// Optional correction profiles by type of object
+	profiles TypeProfiles
+}
+
+// gridMapsOf returns normalized and metric grid maps to use for object
+func (c *GridCorrector) gridMapsOf(obj *events.Object) (*GridMap, *GridMap) {
+	return selectGridMaps(c.profiles.gridMapOf(obj.Type), c.gridMap, c.metricGridMap)
+}
+
+// objectMoveFactorsOf returns normalized and metric objects move factors to use for object
+func (c *GridCorrector) objectMoveFactorsOf(obj *events.Object) (*GridMap, *GridMap) {
+	return selectGridMaps(c.profiles.objectMoveFactorsOf(obj.Type), c.objectMoveFactors, c.metricObjectMoveFactors)
+}
+
+// selectGridMaps replaces default grid maps with profile grid map, if any
+func selectGridMaps(profile, gm, metric *GridMap) (*GridMap, *GridMap) {
+	if profile == nil {
+		return gm, metric
+	}
+	if profile.DistanceUnit == DistanceMm {
+		return gm, profile
+	}
+	return profile, nil
+}
+
+/*
+valueOf search value in grid map according distance of object. Metric grid map is used only if defined and object has
+a metric distance, normalized grid map with bottom position of object is used else.
+
+Metric distances beyond the last step are clamped: the last row applies to all far objects.
+*/
+func (c *GridCorrector) valueOf(gm, metric *GridMap, steering float64, obj *events.Object) (float64, error) {
+	distance, unit := c.distanceSource.Distance(obj)
+	if unit == DistanceMm && metric != nil {
+		return metric.ValueOf(steering, math.Min(distance, metric.DistanceSteps[len(metric.DistanceSteps)-1]))
+	}
+	return gm.ValueOf(steering, float64(obj.Bottom))
+}
+
+/*
+proximityOf returns proximity of object on bottom scale, from 0 far away to 1 near, to compare all objects with the same
+key. When metric grid map is used for object, its distance is mapped on this scale from the distance range of the grid.
+*/
+func (c *GridCorrector) proximityOf(o *events.Object) float64 {
+	distance, unit := c.distanceSource.Distance(o)
+	_, metric := c.gridMapsOf(o)
+	if unit == DistanceMm && metric != nil {
+		maxDistance := metric.DistanceSteps[len(metric.DistanceSteps)-1]
+		return 1. - clamp(distance/maxDistance, 0., 1.)
+	}
+	return bottomProximity(o)
 }
 
 /*
@@ -103,7 +235,7 @@ AdjustFromObjectPosition modify steering value according object positions
     :   | 0.25| 0.5 |  1  |  -1 |-0.5 |-0.25|
     100%|-----|-----|-----|-----|-----|-----|
 
- 2. For straight (current steering near of 0), search nearest object and if:
+ 2. For straight (current steering near of 0), for each object:
 
     * left and right values < 0: use correction from right value according image splitting
     * left and right values > 0: use correction from left value according image splitting
@@ -119,67 +251,194 @@ AdjustFromObjectPosition modify steering value according object positions
     :   | 0.2 | 0.1 |  0  |  0  |-0.1 |-0.2 |
     40% |-----|-----|-----|-----|-----|-----|
     :   | ... | ... | ... | ... | ... | ... |
+
+ 4. Objects are sorted by proximity (DistanceInMm mapped on metric grid range if used, Bottom else) and deviations of objects that matter are
+    combined according to ObjectsStrategy: strongest deviation, sum weighted by proximity or steering to the widest free
+    gap between objects.
+
+ 5. Each type of object can have its own profile: grid maps, safety margin to enlarge object or no correction at all.
 */
 func (c *GridCorrector) AdjustFromObjectPosition(currentSteering float64, objs []*events.Object) float64 {
-	objects := objs
+	value, _ := c.Diagnose(currentSteering, objs)
+	return value
+}
+
+// Diagnose adjusts steering like AdjustFromObjectPosition and explains correction
+func (c *GridCorrector) Diagnose(currentSteering float64, objs []*events.Object) (float64, Diagnosis) {
+	objects := sortByProximity(objs, c.proximityOf)
+	var diagnosis Diagnosis
 
 	zap.S().Debugf("%v objects to avoid", len(objects))
 	if len(objects) == 0 {
-		return currentSteering
+		return currentSteering, diagnosis
+	}
+
+	// Compute deviation for each object, objects without deviation don't matter
+	deviations := make([]objectDeviation, 0, len(objects))
+	for _, o := range objects {
+		profile := c.profiles.profileOf(o.Type)
+		if profile != nil && profile.Ignore {
+			zap.S().Debugf("ignore object of type %v", o.Type)
+			continue
+		}
+		obj := o
+		if profile != nil && profile.SafetyMargin > 0. {
+			obj = inflateObject(o, profile.SafetyMargin)
+		}
+		objMoved, err := c.moveObject(currentSteering, obj)
+		if err != nil {
+			zap.S().Warnf("unable to compute factor to apply to object, ignore it: %v", err)
+			continue
+		}
+		delta, cell := c.computeDeviation(objMoved)
+		if diagnosis.Nearest == nil {
+			diagnosis.Nearest, diagnosis.Cell = o, cell
+		}
+		if delta == 0. {
+			continue
+		}
+		deviations = append(deviations, objectDeviation{object: objMoved, proximity: c.proximityOf(obj), deviation: delta})
 	}
 
-	// get nearest object
-	nearest := objs[0]
+	delta := c.combineDeviations(currentSteering, deviations)
+	diagnosis.Deviation = delta
 
+	result := currentSteering + delta
+	if result < -1. || result > 1. {
+		result = clamp(result, -1., 1.)
+		diagnosis.Clamped = true
+	}
+	return result, diagnosis
+}
+
+/*
+moveObject returns object to use for deviation computing.
+
+When car turns to right or left, search to avoid collision with objects on the right. Apply factor to object to move it
+at middle. This factor is function of distance.
+*/
+func (c *GridCorrector) moveObject(currentSteering float64, obj *events.Object) (*events.Object, error) {
 	if currentSteering > -1*c.deltaMiddle && currentSteering < c.deltaMiddle {
 		// Straight
-		return currentSteering + c.computeDeviation(nearest)
-	} else {
-		// Turn to right or left, so search to avoid collision with objects on the right
-		// Apply factor to object to move it at middle. This factor is function of distance
-		factor, err := c.objectMoveFactors.ValueOf(float64(nearest.Right), float64(nearest.Bottom))
-		if err != nil {
-			zap.S().Warnf("unable to compute factor to apply to object: %v", err)
-			return currentSteering
-		}
-		objMoved := events.Object{
-			Type:       nearest.Type,
-			Left:       nearest.Left + float32(currentSteering*factor),
-			Top:        nearest.Top,
-			Right:      nearest.Right + float32(currentSteering*factor),
-			Bottom:     nearest.Bottom,
-			Confidence: nearest.Confidence,
+		return obj, nil
+	}
+	omf, metricOmf := c.objectMoveFactorsOf(obj)
+	factor, err := c.valueOf(omf, metricOmf, float64(obj.Right), obj)
+	if err != nil {
+		return nil, err
+	}
+	return &events.Object{
+		Type:         obj.Type,
+		Left:         obj.Left + float32(currentSteering*factor),
+		Top:          obj.Top,
+		Right:        obj.Right + float32(currentSteering*factor),
+		Bottom:       obj.Bottom,
+		Confidence:   obj.Confidence,
+		DistanceInMm: obj.DistanceInMm,
+	}, nil
+}
+
+// inflateObject returns a copy of object enlarged by margin on left and right, limited to image borders
+func inflateObject(obj *events.Object, margin float64) *events.Object {
+	return &events.Object{
+		Type:         obj.Type,
+		Left:         float32(clamp(float64(obj.Left)-margin, 0., 1.)),
+		Top:          obj.Top,
+		Right:        float32(clamp(float64(obj.Right)+margin, 0., 1.)),
+		Bottom:       obj.Bottom,
+		Confidence:   obj.Confidence,
+		DistanceInMm: obj.DistanceInMm,
+	}
+}
+
+type objectDeviation struct {
+	object    *events.Object
+	proximity float64
+	deviation float64
+}
+
+// combineDeviations merge deviations of objects sorted by proximity according to strategy
+func (c *GridCorrector) combineDeviations(currentSteering float64, deviations []objectDeviation) float64 {
+	if len(deviations) == 0 {
+		return 0.
+	}
+	switch c.objectsStrategy {
+	case StrategyWeightedSum:
+		return weightedSumDeviation(deviations)
+	case StrategyFreeGap:
+		objects := make([]*events.Object, 0, len(deviations))
+		for _, d := range deviations {
+			objects = append(objects, d.object)
 		}
-		result := currentSteering + c.computeDeviation(&objMoved)
-		if result < -1. {
-			result = -1.
+		gaps := freeGaps(objects, 0.)
+		if len(gaps) == 0 {
+			zap.S().Debugf("no free gap found, fallback to max deviation")
+			return maxMagnitudeDeviation(deviations)
 		}
-		if result > 1. {
-			result = 1.
+		widest := widestGap(gaps)
+		zap.S().Debugf("steer to free gap [%v, %v]", widest.left, widest.right)
+		return widest.steering() - currentSteering
+	default:
+		return maxMagnitudeDeviation(deviations)
+	}
+}
+
+// maxMagnitudeDeviation returns the strongest deviation, nearest object wins on equality
+func maxMagnitudeDeviation(deviations []objectDeviation) float64 {
+	result := deviations[0].deviation
+	for _, d := range deviations[1:] {
+		if math.Abs(d.deviation) > math.Abs(result) {
+			result = d.deviation
 		}
-		return result
 	}
+	return result
+}
+
+// weightedSumDeviation averages deviations weighted by object proximity, result is never stronger than strongest deviation
+func weightedSumDeviation(deviations []objectDeviation) float64 {
+	var result, totalWeight float64
+	for _, d := range deviations {
+		result += d.deviation * d.proximity
+		totalWeight += d.proximity
+	}
+	if totalWeight <= 0. {
+		return deviations[0].deviation
+	}
+	return result / totalWeight
 }
 
-func (c *GridCorrector) computeDeviation(nearest *events.Object) float64 {
-	var delta float64
-	var err error
+// computeDeviation returns deviation to avoid object and grid map cell used to compute it
+func (c *GridCorrector) computeDeviation(nearest *events.Object) (float64, *GridCell) {
+	var position float64
 
-	zap.S().Debugf("search delta value for bottom limit: %v", nearest.Bottom)
+	gm, metric := c.gridMapsOf(nearest)
+	zap.S().Debugf("search delta value for bottom limit: %v, distance: %vmm", nearest.Bottom, nearest.DistanceInMm)
 	if nearest.Left < 0 && nearest.Right < 0 {
-		delta, err = c.gridMap.ValueOf(float64(nearest.Right)*2-1., float64(nearest.Bottom))
+		position = float64(nearest.Right)*2 - 1.
 	}
 	if nearest.Left > 0 && nearest.Right > 0 {
-		delta, err = c.gridMap.ValueOf(float64(nearest.Left)*2-1., float64(nearest.Bottom))
+		position = float64(nearest.Left)*2 - 1.
 	} else {
-		delta, err = c.gridMap.ValueOf(float64(float64(nearest.Left)+(float64(nearest.Right)-float64(nearest.Left))/2.)*2.-1., float64(nearest.Bottom))
+		position = float64(float64(nearest.Left)+(float64(nearest.Right)-float64(nearest.Left))/2.)*2. - 1.
 	}
+	delta, err := c.valueOf(gm, metric, position, nearest)
 	if err != nil {
 		zap.S().Warnf("unable to compute delta to apply to steering, skip correction: %v", err)
-		delta = 0
+		return 0., nil
 	}
 	zap.S().Debugf("new deviation computed: %v", delta)
-	return delta
+	return delta, c.cellOf(gm, metric, position, nearest)
+}
+
+// cellOf returns grid map cell of steering and object distance, like valueOf
+func (c *GridCorrector) cellOf(gm, metric *GridMap, steering float64, obj *events.Object) *GridCell {
+	distance, unit := c.distanceSource.Distance(obj)
+	if unit == DistanceMm && metric != nil {
+		cell := metric.CellOf(steering, math.Min(distance, metric.DistanceSteps[len(metric.DistanceSteps)-1]))
+		return &cell
+	}
+	cell := gm.CellOf(steering, float64(obj.Bottom))
+	return &cell
 }
 
 func NewGridMapFromJson(fileName string) (*GridMap, error) {
@@ -192,14 +451,102 @@ func NewGridMapFromJson(fileName string) (*GridMap, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to unmarshal json content from %s file: %w", fileName, err)
 	}
-	// TODO: check structure is valid
+	if err := ft.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid grid map from %s file: %w", fileName, err)
+	}
 	return &ft, nil
 }
 
+// Interpolation defines how GridMap computes a value between cells
+type Interpolation string
+
+const (
+	// InterpolationNearest returns raw cell value (step lookup)
+	InterpolationNearest Interpolation = "nearest"
+	// InterpolationBilinear blends the 4 neighbour cells
+	InterpolationBilinear Interpolation = "bilinear"
+	// InterpolationBicubic blends the 16 neighbour cells with Catmull-Rom splines
+	InterpolationBicubic Interpolation = "bicubic"
+)
+
 type GridMap struct {
-	DistanceSteps []float64   `json:"distance_steps"`
-	SteeringSteps []float64   `json:"steering_steps"`
-	Data          [][]float64 `json:"data"`
+	DistanceSteps []float64     `json:"distance_steps"`
+	SteeringSteps []float64     `json:"steering_steps"`
+	Data          [][]float64   `json:"data"`
+	Interpolation Interpolation `json:"interpolation,omitempty"`
+	DistanceUnit  DistanceUnit  `json:"distance_unit,omitempty"`
+}
+
+/*
+Validate checks grid map structure:
+
+  - steering and distance steps are strictly increasing and cover [-1,1]x[0,1], distance steps in millimeters have
+    only to start at 0
+  - data has len(distance_steps)-1 rows and len(steering_steps)-1 columns
+  - values are finite numbers
+  - interpolation mode is supported
+
+All problems are reported in returned error.
+*/
+func (f *GridMap) Validate() error {
+	var errs []error
+	errs = append(errs, validateSteps("steering_steps", f.SteeringSteps, -1., 1.)...)
+	switch f.DistanceUnit {
+	case "", DistanceNormalized:
+		errs = append(errs, validateSteps("distance_steps", f.DistanceSteps, 0., 1.)...)
+	case DistanceMm:
+		errs = append(errs, validateSteps("distance_steps", f.DistanceSteps, 0., 0.)...)
+	default:
+		errs = append(errs, fmt.Errorf("distance_unit: unsupported unit '%v'", f.DistanceUnit))
+	}
+
+	if len(f.DistanceSteps) > 0 && len(f.Data) != len(f.DistanceSteps)-1 {
+		errs = append(errs, fmt.Errorf("data: %d rows, want %d (len(distance_steps)-1)", len(f.Data), len(f.DistanceSteps)-1))
+	}
+	for row, values := range f.Data {
+		if len(f.SteeringSteps) > 0 && len(values) != len(f.SteeringSteps)-1 {
+			errs = append(errs, fmt.Errorf("data row %d: %d columns, want %d (len(steering_steps)-1)", row, len(values), len(f.SteeringSteps)-1))
+		}
+		for col, v := range values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				errs = append(errs, fmt.Errorf("data row %d, column %d: invalid value %v", row, col, v))
+			}
+		}
+	}
+
+	switch f.Interpolation {
+	case "", InterpolationNearest, InterpolationBilinear, InterpolationBicubic:
+	default:
+		errs = append(errs, fmt.Errorf("interpolation: unsupported mode '%v'", f.Interpolation))
+	}
+	return errors.Join(errs...)
+}
+
+func validateSteps(name string, steps []float64, min, max float64) []error {
+	if len(steps) < 2 {
+		return []error{fmt.Errorf("%s: %d values, want at least 2", name, len(steps))}
+	}
+	var errs []error
+	for i, v := range steps {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			errs = append(errs, fmt.Errorf("%s[%d]: invalid value %v", name, i, v))
+			continue
+		}
+		if i > 0 && v <= steps[i-1] {
+			errs = append(errs, fmt.Errorf("%s[%d]: %v must be greater than previous value %v", name, i, v, steps[i-1]))
+		}
+	}
+	if steps[0] > min || steps[len(steps)-1] < max {
+		errs = append(errs, fmt.Errorf("%s: range [%v,%v] doesn't cover [%v,%v]", name, steps[0], steps[len(steps)-1], min, max))
+	}
+	return errs
+}
+
+// WithInterpolation returns a copy of grid map that use interpolation mode i
+func (f *GridMap) WithInterpolation(i Interpolation) *GridMap {
+	gm := *f
+	gm.Interpolation = i
+	return &gm
 }
 
 func (f *GridMap) ValueOf(steering float64, distance float64) (float64, error) {
@@ -209,8 +556,34 @@ func (f *GridMap) ValueOf(steering float64, distance float64) (float64, error) {
 	if distance < f.DistanceSteps[0] || distance > f.DistanceSteps[len(f.DistanceSteps)-1] {
 		return 0., fmt.Errorf("invalid distance value: %v, must be between %v and %v", steering, f.DistanceSteps[0], f.DistanceSteps[len(f.DistanceSteps)-1])
 	}
-	// search column index
-	var idxCol int
+
+	switch f.Interpolation {
+	case "", InterpolationNearest:
+		return f.nearestValueOf(steering, distance), nil
+	case InterpolationBilinear:
+		return f.bilinearValueOf(steering, distance), nil
+	case InterpolationBicubic:
+		return f.bicubicValueOf(steering, distance), nil
+	default:
+		return 0., fmt.Errorf("invalid interpolation mode: '%v'", f.Interpolation)
+	}
+}
+
+func (f *GridMap) nearestValueOf(steering float64, distance float64) float64 {
+	cell := f.CellOf(steering, distance)
+	return f.Data[cell.Row][cell.Col]
+}
+
+// GridCell identifies a cell of grid map data
+type GridCell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// CellOf returns cell of grid map that contains steering and distance, values out of grid are in border cells
+func (f *GridMap) CellOf(steering float64, distance float64) GridCell {
+	// search column index, last column is used for the upper limit
+	idxCol := len(f.SteeringSteps) - 2
 	// Start loop at 1 because first column should be skipped
 	for i := 1; i < len(f.SteeringSteps); i++ {
 		if steering < f.SteeringSteps[i] {
@@ -219,7 +592,7 @@ func (f *GridMap) ValueOf(steering float64, distance float64) (float64, error) {
 		}
 	}
 
-	var idxRow int
+	idxRow := len(f.DistanceSteps) - 2
 	// Start loop at 1 because first column should be skipped
 	for i := 1; i < len(f.DistanceSteps); i++ {
 		if distance < f.DistanceSteps[i] {
@@ -228,5 +601,93 @@ func (f *GridMap) ValueOf(steering float64, distance float64) (float64, error) {
 		}
 	}
 
-	return f.Data[idxRow][idxCol], nil
+	return GridCell{Row: idxRow, Col: idxCol}
+}
+
+/*
+bilinearValueOf considers each cell value as defined at the centre of the cell and blends the 4 nearest cell centres.
+Beyond the first and last centres, value is extended from the border cells.
+*/
+func (f *GridMap) bilinearValueOf(steering float64, distance float64) float64 {
+	col, tCol := cellPosition(f.SteeringSteps, steering)
+	row, tRow := cellPosition(f.DistanceSteps, distance)
+
+	maxCol := len(f.SteeringSteps) - 2
+	maxRow := len(f.DistanceSteps) - 2
+	top := lerp(f.cell(row, col, maxRow, maxCol), f.cell(row, col+1, maxRow, maxCol), tCol)
+	bottom := lerp(f.cell(row+1, col, maxRow, maxCol), f.cell(row+1, col+1, maxRow, maxCol), tCol)
+	return lerp(top, bottom, tRow)
+}
+
+/*
+bicubicValueOf applies Catmull-Rom interpolation on cell centres, using 4 cells on each axis. Like bilinear mode, border
+cells are repeated outside the grid.
+*/
+func (f *GridMap) bicubicValueOf(steering float64, distance float64) float64 {
+	col, tCol := cellPosition(f.SteeringSteps, steering)
+	row, tRow := cellPosition(f.DistanceSteps, distance)
+
+	maxCol := len(f.SteeringSteps) - 2
+	maxRow := len(f.DistanceSteps) - 2
+	var rows [4]float64
+	for i := 0; i < 4; i++ {
+		r := row - 1 + i
+		rows[i] = catmullRom(
+			f.cell(r, col-1, maxRow, maxCol),
+			f.cell(r, col, maxRow, maxCol),
+			f.cell(r, col+1, maxRow, maxCol),
+			f.cell(r, col+2, maxRow, maxCol),
+			tCol,
+		)
+	}
+	return catmullRom(rows[0], rows[1], rows[2], rows[3], tRow)
+}
+
+// cell returns data value, indexes out of grid are clamped on border cells
+func (f *GridMap) cell(row, col, maxRow, maxCol int) float64 {
+	return f.Data[clampIndex(row, maxRow)][clampIndex(col, maxCol)]
+}
+
+/*
+cellPosition returns index of cell whose centre is just before value and the normalized position of value between this
+centre and the next one. Values outside centres range are clamped to the first or last cell, with a position of 0.
+*/
+func cellPosition(steps []float64, value float64) (int, float64) {
+	nbCells := len(steps) - 1
+	centre := func(i int) float64 {
+		return (steps[i] + steps[i+1]) / 2.
+	}
+	if value <= centre(0) {
+		return 0, 0.
+	}
+	if value >= centre(nbCells-1) {
+		return nbCells - 1, 0.
+	}
+	for i := 0; i < nbCells-1; i++ {
+		if value < centre(i+1) {
+			return i, (value - centre(i)) / (centre(i+1) - centre(i))
+		}
+	}
+	return nbCells - 1, 0.
+}
+
+func clampIndex(i, max int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func catmullRom(p0, p1, p2, p3, t float64) float64 {
+	return 0.5 * (2*p1 +
+		(-p0+p2)*t +
+		(2*p0-5*p1+4*p2-p3)*t*t +
+		(-p0+3*p1-3*p2+p3)*t*t*t)
 }
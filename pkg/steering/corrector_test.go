@@ -1,8 +1,11 @@
 package steering
 
 import (
+	"fmt"
 	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -184,6 +187,13 @@ func TestNewGridMapFromJson(t *testing.T) {
 			},
 			want: &defaultGridMap,
 		},
+		{
+			name: "invalid config",
+			args: args{
+				fileName: "test_data/invalid-config.json",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -192,6 +202,9 @@ func TestNewGridMapFromJson(t *testing.T) {
 				t.Errorf("NewGridMapFromJson() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			if !reflect.DeepEqual(*got, *tt.want) {
 				t.Errorf("NewGridMapFromJson() got = %v, want %v", got, tt.want)
 			}
@@ -292,6 +305,20 @@ func TestGridMap_ValueOf(t *testing.T) {
 			want:    0.5,
 			wantErr: false,
 		},
+		{
+			name: "upper limits",
+			fields: fields{
+				DistanceSteps: defaultGridMap.DistanceSteps,
+				SteeringSteps: defaultGridMap.SteeringSteps,
+				Data:          defaultGridMap.Data,
+			},
+			args: args{
+				steering: 1.,
+				distance: 1.,
+			},
+			want:    -0.25,
+			wantErr: false,
+		},
 		{
 			name: "steering < min value",
 			fields: fields{
@@ -369,9 +396,10 @@ func TestWithGridMap(t *testing.T) {
 		config string
 	}
 	tests := []struct {
-		name string
-		args args
-		want GridMap
+		name    string
+		args    args
+		want    GridMap
+		wantErr bool
 	}{
 		{
 			name: "default value",
@@ -383,11 +411,28 @@ func TestWithGridMap(t *testing.T) {
 			args: args{config: "test_data/config.json"},
 			want: defaultGridMap,
 		},
+		{
+			name:    "invalid config",
+			args:    args{config: "test_data/invalid-config.json"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			args:    args{config: "test_data/missing.json"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := GridCorrector{}
-			got := WithGridMap(tt.args.config)
+			got, err := WithGridMap(tt.args.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithGridMap() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
 			got(&c)
 			if !reflect.DeepEqual(*c.gridMap, tt.want) {
 				t.Errorf("WithGridMap() = %v, want %v", *c.gridMap, tt.want)
@@ -396,14 +441,30 @@ func TestWithGridMap(t *testing.T) {
 	}
 }
 
+func TestWithGridMap_Metric(t *testing.T) {
+	c := GridCorrector{gridMap: &defaultGridMap}
+	got, err := WithGridMap("test_data/config-mm.json")
+	if err != nil {
+		t.Fatalf("WithGridMap() error = %v", err)
+	}
+	got(&c)
+	if c.metricGridMap == nil || c.metricGridMap.DistanceUnit != DistanceMm {
+		t.Errorf("WithGridMap() metric grid map = %v, want grid map in mm", c.metricGridMap)
+	}
+	if !reflect.DeepEqual(*c.gridMap, defaultGridMap) {
+		t.Errorf("WithGridMap() normalized grid map = %v, want %v", *c.gridMap, defaultGridMap)
+	}
+}
+
 func TestWithObjectMoveFactors(t *testing.T) {
 	type args struct {
 		config string
 	}
 	tests := []struct {
-		name string
-		args args
-		want GridMap
+		name    string
+		args    args
+		want    GridMap
+		wantErr bool
 	}{
 		{
 			name: "default value",
@@ -415,11 +476,23 @@ func TestWithObjectMoveFactors(t *testing.T) {
 			args: args{config: "test_data/omf-config.json"},
 			want: defaultObjectFactors,
 		},
+		{
+			name:    "invalid config",
+			args:    args{config: "test_data/invalid-config.json"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := GridCorrector{}
-			got := WithObjectMoveFactors(tt.args.config)
+			got, err := WithObjectMoveFactors(tt.args.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithObjectMoveFactors() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
 			got(&c)
 			if !reflect.DeepEqual(*c.objectMoveFactors, tt.want) {
 				t.Errorf("WithObjectMoveFactors() = %v, want %v", *c.objectMoveFactors, tt.want)
@@ -427,3 +500,539 @@ func TestWithObjectMoveFactors(t *testing.T) {
 		})
 	}
 }
+
+func TestGridMap_ValueOf_Interpolation(t *testing.T) {
+	epsilon := 1e-6
+	tests := []struct {
+		name          string
+		interpolation Interpolation
+		steering      float64
+		distance      float64
+		want          float64
+	}{
+		{
+			name:          "bilinear on cell centre",
+			interpolation: InterpolationBilinear,
+			steering:      -0.165,
+			distance:      0.9,
+			want:          1.,
+		},
+		{
+			name:          "bilinear between 2 columns",
+			interpolation: InterpolationBilinear,
+			steering:      0.,
+			distance:      0.9,
+			want:          0.,
+		},
+		{
+			name:          "bilinear between 2 rows",
+			interpolation: InterpolationBilinear,
+			steering:      -0.165,
+			distance:      0.8,
+			want:          0.75,
+		},
+		{
+			name:          "bilinear on border",
+			interpolation: InterpolationBilinear,
+			steering:      -1.,
+			distance:      1.,
+			want:          0.25,
+		},
+		{
+			name:          "bicubic on cell centre",
+			interpolation: InterpolationBicubic,
+			steering:      -0.165,
+			distance:      0.9,
+			want:          1.,
+		},
+		{
+			name:          "bicubic between 2 symmetric columns",
+			interpolation: InterpolationBicubic,
+			steering:      0.,
+			distance:      0.9,
+			want:          0.,
+		},
+		{
+			name:          "nearest",
+			interpolation: InterpolationNearest,
+			steering:      -0.165,
+			distance:      0.8,
+			want:          1.,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm := defaultGridMap.WithInterpolation(tt.interpolation)
+			got, err := gm.ValueOf(tt.steering, tt.distance)
+			if err != nil {
+				t.Errorf("ValueOf() error = %v", err)
+				return
+			}
+			if math.Abs(got-tt.want) > epsilon {
+				t.Errorf("ValueOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridMap_ValueOf_Continuity(t *testing.T) {
+	epsilon := 1e-6
+	type args struct {
+		steering float64
+		distance float64
+	}
+	boundaries := []struct {
+		name   string
+		before args
+		after  args
+	}{
+		{
+			name:   "distance 0.6",
+			before: args{steering: -0.2, distance: 0.6 - epsilon},
+			after:  args{steering: -0.2, distance: 0.6 + epsilon},
+		},
+		{
+			name:   "distance 0.8",
+			before: args{steering: -0.2, distance: 0.8 - epsilon},
+			after:  args{steering: -0.2, distance: 0.8 + epsilon},
+		},
+		{
+			name:   "steering -0.33",
+			before: args{steering: -0.33 - epsilon, distance: 0.85},
+			after:  args{steering: -0.33 + epsilon, distance: 0.85},
+		},
+		{
+			name:   "steering 0",
+			before: args{steering: -epsilon, distance: 0.85},
+			after:  args{steering: epsilon, distance: 0.85},
+		},
+	}
+	tests := []struct {
+		interpolation Interpolation
+		continuous    bool
+	}{
+		{interpolation: InterpolationNearest, continuous: false},
+		{interpolation: InterpolationBilinear, continuous: true},
+		{interpolation: InterpolationBicubic, continuous: true},
+	}
+	for _, tt := range tests {
+		for _, b := range boundaries {
+			t.Run(fmt.Sprintf("%v %v", tt.interpolation, b.name), func(t *testing.T) {
+				gm := defaultGridMap.WithInterpolation(tt.interpolation)
+				before, err := gm.ValueOf(b.before.steering, b.before.distance)
+				if err != nil {
+					t.Errorf("ValueOf() error = %v", err)
+					return
+				}
+				after, err := gm.ValueOf(b.after.steering, b.after.distance)
+				if err != nil {
+					t.Errorf("ValueOf() error = %v", err)
+					return
+				}
+				// Step between values must be proportional to step between args
+				continuous := math.Abs(after-before) < 100*epsilon
+				if continuous != tt.continuous {
+					t.Errorf("ValueOf() jumps from %v to %v, continuous: %v, want %v", before, after, continuous, tt.continuous)
+				}
+			})
+		}
+	}
+}
+
+func TestGridCorrector_WithInterpolation(t *testing.T) {
+	gm, err := WithGridMap("test_data/config.json")
+	if err != nil {
+		t.Fatalf("unable to load grid map: %v", err)
+	}
+	c := NewGridCorrector(gm, WithInterpolation(InterpolationBilinear))
+	if c.gridMap.Interpolation != InterpolationBilinear {
+		t.Errorf("bad grid map interpolation: %v, want %v", c.gridMap.Interpolation, InterpolationBilinear)
+	}
+	if c.objectMoveFactors.Interpolation != InterpolationBilinear {
+		t.Errorf("bad objects move factors interpolation: %v, want %v", c.objectMoveFactors.Interpolation, InterpolationBilinear)
+	}
+	if defaultObjectFactors.Interpolation != "" {
+		t.Errorf("default objects move factors must not be modified")
+	}
+}
+
+func TestGridMap_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		gridMap GridMap
+		// errors expected in error message
+		wantErrs []string
+	}{
+		{
+			name:    "default grid map",
+			gridMap: defaultGridMap,
+		},
+		{
+			name:    "default objects move factors",
+			gridMap: defaultObjectFactors,
+		},
+		{
+			name: "steps not increasing",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 0.6, 0.5, 1.},
+				SteeringSteps: []float64{-1., 0., 0., 1.},
+				Data:          [][]float64{{0., 0., 0.}, {0., 0., 0.}, {0., 0., 0.}},
+			},
+			wantErrs: []string{
+				"distance_steps[2]: 0.5 must be greater than previous value 0.6",
+				"steering_steps[2]: 0 must be greater than previous value 0",
+			},
+		},
+		{
+			name: "bad rows count",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 0.5, 1.},
+				SteeringSteps: []float64{-1., 0., 1.},
+				Data:          [][]float64{{0., 0.}},
+			},
+			wantErrs: []string{"data: 1 rows, want 2"},
+		},
+		{
+			name: "bad columns count",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 0.5, 1.},
+				SteeringSteps: []float64{-1., 0., 1.},
+				Data:          [][]float64{{0., 0.}, {0., 0., 0.}},
+			},
+			wantErrs: []string{"data row 1: 3 columns, want 2"},
+		},
+		{
+			name: "NaN and Inf values",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 0.5, 1.},
+				SteeringSteps: []float64{-1., 0., 1.},
+				Data:          [][]float64{{0., math.NaN()}, {math.Inf(-1), 0.}},
+			},
+			wantErrs: []string{
+				"data row 0, column 1: invalid value NaN",
+				"data row 1, column 0: invalid value -Inf",
+			},
+		},
+		{
+			name: "range not covered",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0.2, 0.5, 1.},
+				SteeringSteps: []float64{-1., 0., 0.9},
+				Data:          [][]float64{{0., 0.}, {0., 0.}},
+			},
+			wantErrs: []string{
+				"steering_steps: range [-1,0.9] doesn't cover [-1,1]",
+				"distance_steps: range [0.2,1] doesn't cover [0,1]",
+			},
+		},
+		{
+			name: "missing steps",
+			gridMap: GridMap{
+				SteeringSteps: []float64{-1., 0., 1.},
+				Data:          [][]float64{{0., 0.}},
+			},
+			wantErrs: []string{"distance_steps: 0 values, want at least 2"},
+		},
+		{
+			name: "distance in mm",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 500., 1000.},
+				SteeringSteps: []float64{-1., 0., 1.},
+				Data:          [][]float64{{0., 0.}, {0., 0.}},
+				DistanceUnit:  DistanceMm,
+			},
+		},
+		{
+			name: "bad distance unit",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 1.},
+				SteeringSteps: []float64{-1., 1.},
+				Data:          [][]float64{{0.}},
+				DistanceUnit:  "cm",
+			},
+			wantErrs: []string{"distance_unit: unsupported unit 'cm'"},
+		},
+		{
+			name: "bad interpolation",
+			gridMap: GridMap{
+				DistanceSteps: []float64{0., 1.},
+				SteeringSteps: []float64{-1., 1.},
+				Data:          [][]float64{{0.}},
+				Interpolation: "linear",
+			},
+			wantErrs: []string{"interpolation: unsupported mode 'linear'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gridMap.Validate()
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Errorf("Validate() error = %v, wantErrs %v", err, tt.wantErrs)
+				return
+			}
+			for _, e := range tt.wantErrs {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("Validate() error = %v, want contains '%v'", err, e)
+				}
+			}
+		})
+	}
+}
+
+func TestGridCorrector_AdjustFromObjectPosition_Strategies(t *testing.T) {
+	objectOnRightMiddleDistance := events.Object{
+		Type:       events.TypeObject_ANY,
+		Left:       0.7,
+		Top:        0.6,
+		Right:      0.9,
+		Bottom:     0.7,
+		Confidence: 0.9,
+	}
+	type args struct {
+		currentSteering float64
+		objects         []*events.Object
+	}
+	tests := []struct {
+		name     string
+		strategy ObjectsStrategy
+		args     args
+		want     float64
+	}{
+		{
+			name:     "max: distant object listed first doesn't hide near object",
+			strategy: StrategyMaxMagnitude,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnMiddleDistant, &objectOnLeftNear},
+			},
+			want: 0.25,
+		},
+		{
+			name:     "max: strongest deviation wins",
+			strategy: StrategyMaxMagnitude,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnLeftNear, &objectOnRightNear},
+			},
+			want: -0.5,
+		},
+		{
+			name:     "max: only distant objects",
+			strategy: StrategyMaxMagnitude,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnLeftDistant, &objectOnRightDistant},
+			},
+			want: 0.,
+		},
+		{
+			name:     "weighted: objects at same distance",
+			strategy: StrategyWeightedSum,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnLeftNear, &objectOnRightNear},
+			},
+			want: (0.25 - 0.5) / 2.,
+		},
+		{
+			name:     "weighted: farthest object has less weight",
+			strategy: StrategyWeightedSum,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnRightMiddleDistance, &objectOnLeftNear},
+			},
+			want: (0.25*0.9 - 0.25*0.7) / (0.9 + 0.7),
+		},
+		{
+			name:     "weighted: depth is ignored without metric grid map",
+			strategy: StrategyWeightedSum,
+			args: args{
+				currentSteering: 0.,
+				objects: []*events.Object{
+					{Left: 0.1, Top: 0.8, Right: 0.3, Bottom: 0.9, Confidence: 0.9, DistanceInMm: 1000},
+					{Left: 0.7, Top: 0.8, Right: 0.9, Bottom: 0.9, Confidence: 0.9, DistanceInMm: 500},
+				},
+			},
+			want: (0.25 - 0.5) / 2.,
+		},
+		{
+			name:     "gap: steer to the free gap between objects",
+			strategy: StrategyFreeGap,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnLeftNear, &objectOnRightNear},
+			},
+			want: 0.,
+		},
+		{
+			name:     "gap: steer to the widest free gap",
+			strategy: StrategyFreeGap,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnRightNear, &objectOnMiddleNear},
+			},
+			want: -0.6,
+		},
+		{
+			name:     "gap: distant objects are ignored",
+			strategy: StrategyFreeGap,
+			args: args{
+				currentSteering: 0.,
+				objects:         []*events.Object{&objectOnMiddleDistant, &objectOnRightDistant},
+			},
+			want: 0.,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector(WithObjectsStrategy(tt.strategy))
+			if got := c.AdjustFromObjectPosition(tt.args.currentSteering, tt.args.objects); math.Abs(got-tt.want) > 1e-5 {
+				t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridCorrector_Diagnose_Clamping(t *testing.T) {
+	// Deviations of grid map cells can be stronger than steering limits
+	strongGridMap := &GridMap{
+		DistanceSteps: []float64{0., 0.5, 1.},
+		SteeringSteps: []float64{-1., 0., 1.},
+		Data:          [][]float64{{0., 0.}, {3., -3.}},
+	}
+	centredNear := []*events.Object{&objectOnMiddleNear, &objectOnMiddleNear, &objectOnMiddleNear}
+	tests := []struct {
+		name            string
+		strategy        ObjectsStrategy
+		gridMap         *GridMap
+		currentSteering float64
+		objects         []*events.Object
+		wantClamped     bool
+	}{
+		{name: "weighted: several near objects", strategy: StrategyWeightedSum, gridMap: &defaultGridMap, currentSteering: 0., objects: centredNear},
+		{name: "weighted: several near objects on turn", strategy: StrategyWeightedSum, gridMap: &defaultGridMap, currentSteering: 0.5, objects: centredNear},
+		{name: "weighted: strong grid on straight", strategy: StrategyWeightedSum, gridMap: strongGridMap, currentSteering: 0., objects: centredNear, wantClamped: true},
+		{name: "max: strong grid on straight", strategy: StrategyMaxMagnitude, gridMap: strongGridMap, currentSteering: 0.05, objects: centredNear, wantClamped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector(WithObjectsStrategy(tt.strategy))
+			c.gridMap = tt.gridMap
+			got, diagnosis := c.Diagnose(tt.currentSteering, tt.objects)
+			if math.Abs(got) > 1. {
+				t.Errorf("Diagnose() = %v, must be in [-1, 1]", got)
+			}
+			if diagnosis.Clamped != tt.wantClamped {
+				t.Errorf("bad clamping: %v, want %v", diagnosis.Clamped, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestGridCorrector_Diagnose_InvalidObject(t *testing.T) {
+	// Nearest object is out of image, no factor can be computed to move it on turn
+	invalidObject := events.Object{Type: events.TypeObject_ANY, Left: 0.9, Top: 0.8, Right: 1.5, Bottom: 0.95, Confidence: 0.9}
+	c := NewGridCorrector()
+
+	want, _ := c.Diagnose(0.5, []*events.Object{&objectOnMiddleNear})
+	if want == 0.5 {
+		t.Fatalf("valid object must be corrected")
+	}
+	got, diagnosis := c.Diagnose(0.5, []*events.Object{&invalidObject, &objectOnMiddleNear})
+	if got != want {
+		t.Errorf("Diagnose() = %v, want %v", got, want)
+	}
+	if diagnosis.Nearest != &objectOnMiddleNear {
+		t.Errorf("bad nearest object: %v, want %v", diagnosis.Nearest, &objectOnMiddleNear)
+	}
+}
+
+func TestGridCorrector_Diagnose(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentSteering float64
+		objects         []*events.Object
+		want            float64
+		wantNearest     *events.Object
+		wantCell        *GridCell
+		wantDeviation   float64
+		wantClamped     bool
+	}{
+		{
+			name:            "no object",
+			currentSteering: 0.3,
+			want:            0.3,
+		},
+		{
+			name:            "nearest object and its cell",
+			currentSteering: 0.,
+			objects:         []*events.Object{&objectOnMiddleDistant, &objectOnMiddleNear},
+			want:            1.,
+			wantNearest:     &objectOnMiddleNear,
+			wantCell:        &GridCell{Row: 4, Col: 2},
+			wantDeviation:   1.,
+		},
+		{
+			name:            "distant object without deviation",
+			currentSteering: 0.,
+			objects:         []*events.Object{&objectOnMiddleDistant},
+			want:            0.,
+			wantNearest:     &objectOnMiddleDistant,
+			wantCell:        &GridCell{Row: 1, Col: 2},
+		},
+		{
+			name:            "turn clamped",
+			currentSteering: 0.9,
+			objects:         []*events.Object{&objectOnMiddleNear},
+			want:            1.,
+			wantNearest:     &objectOnMiddleNear,
+			wantCell:        &GridCell{Row: 4, Col: 0},
+			wantDeviation:   0.25,
+			wantClamped:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector()
+			got, diagnosis := c.Diagnose(tt.currentSteering, tt.objects)
+			if math.Abs(got-tt.want) > 1e-5 {
+				t.Errorf("Diagnose() = %v, want %v", got, tt.want)
+			}
+			if got != c.AdjustFromObjectPosition(tt.currentSteering, tt.objects) {
+				t.Errorf("Diagnose() = %v, differs from AdjustFromObjectPosition()", got)
+			}
+			if diagnosis.Nearest != tt.wantNearest {
+				t.Errorf("bad nearest object: %v, want %v", diagnosis.Nearest, tt.wantNearest)
+			}
+			if !reflect.DeepEqual(diagnosis.Cell, tt.wantCell) {
+				t.Errorf("bad grid cell: %v, want %v", diagnosis.Cell, tt.wantCell)
+			}
+			if math.Abs(diagnosis.Deviation-tt.wantDeviation) > 1e-5 {
+				t.Errorf("bad deviation: %v, want %v", diagnosis.Deviation, tt.wantDeviation)
+			}
+			if diagnosis.Clamped != tt.wantClamped {
+				t.Errorf("bad clamping: %v, want %v", diagnosis.Clamped, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestGridMap_CellOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		steering float64
+		distance float64
+		want     GridCell
+	}{
+		{name: "first cell", steering: -1., distance: 0., want: GridCell{Row: 0, Col: 0}},
+		{name: "middle cell", steering: 0.1, distance: 0.5, want: GridCell{Row: 2, Col: 3}},
+		{name: "upper limits in last cell", steering: 1., distance: 1., want: GridCell{Row: 4, Col: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultGridMap.CellOf(tt.steering, tt.distance); got != tt.want {
+				t.Errorf("CellOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"time"
+)
+
+/*
+DebugRecord explains how a published steering value has been computed, it is published as JSON on debug topic for
+each steering output.
+*/
+type DebugRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	FrameId   string    `json:"frame_id,omitempty"`
+	// Input is the steering value received, blended with model on copilot drive mode
+	Input     float64 `json:"input"`
+	Source    Source  `json:"source"`
+	DriveMode string  `json:"drive_mode"`
+	// Objects are objects used by pipeline, empty if objects correction hasn't run
+	Objects  []DebugObject `json:"objects"`
+	Nearest  *DebugObject  `json:"nearest,omitempty"`
+	GridCell *GridCell     `json:"grid_cell,omitempty"`
+	// Deviation is the correction computed from objects, before gain and clamping
+	Deviation float64 `json:"deviation"`
+	// Correction is the steering change applied by objects correction
+	Correction float64 `json:"correction"`
+	Clamped    bool    `json:"clamped"`
+	Value      float64 `json:"value"`
+}
+
+// DebugObject is an object to avoid, with readable type
+type DebugObject struct {
+	Type         string  `json:"type"`
+	Left         float32 `json:"left"`
+	Top          float32 `json:"top"`
+	Right        float32 `json:"right"`
+	Bottom       float32 `json:"bottom"`
+	Confidence   float32 `json:"confidence"`
+	DistanceInMm int64   `json:"distance_in_mm,omitempty"`
+}
+
+// NewDebugRecord returns record of steering computed from input by pipeline
+func NewDebugRecord(input float64, s *Steering) *DebugRecord {
+	objects := s.loadedObjects()
+	r := &DebugRecord{
+		Timestamp:  s.Timestamp,
+		FrameId:    s.FrameRef.GetId(),
+		Input:      input,
+		Source:     s.Source,
+		DriveMode:  s.DriveMode.String(),
+		Objects:    make([]DebugObject, 0, len(objects)),
+		Correction: s.Correction,
+		Value:      s.Value,
+	}
+	for _, o := range objects {
+		r.Objects = append(r.Objects, newDebugObject(o))
+	}
+	if s.Diagnosis != nil {
+		if s.Diagnosis.Nearest != nil {
+			nearest := newDebugObject(s.Diagnosis.Nearest)
+			r.Nearest = &nearest
+		}
+		r.GridCell = s.Diagnosis.Cell
+		r.Deviation = s.Diagnosis.Deviation
+		r.Clamped = s.Diagnosis.Clamped
+	}
+	return r
+}
+
+func newDebugObject(o *events.Object) DebugObject {
+	return DebugObject{
+		Type:         o.GetType().String(),
+		Left:         o.GetLeft(),
+		Top:          o.GetTop(),
+		Right:        o.GetRight(),
+		Bottom:       o.GetBottom(),
+		Confidence:   o.GetConfidence(),
+		DistanceInMm: o.GetDistanceInMm(),
+	}
+}
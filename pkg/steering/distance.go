@@ -0,0 +1,57 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"sort"
+)
+
+// DistanceUnit defines unit of GridMap distance steps
+type DistanceUnit string
+
+const (
+	// DistanceNormalized is the bottom position of object in image, 0 on top and 1 on bottom
+	DistanceNormalized DistanceUnit = "normalized"
+	// DistanceMm is the metric distance of object, in millimeters
+	DistanceMm DistanceUnit = "mm"
+)
+
+// DistanceSource computes distance of object to use to search correction on grid map rows
+type DistanceSource interface {
+	Distance(o *events.Object) (float64, DistanceUnit)
+}
+
+// BottomDistance uses bottom position of object in image as distance
+type BottomDistance struct{}
+
+func (b BottomDistance) Distance(o *events.Object) (float64, DistanceUnit) {
+	return float64(o.Bottom), DistanceNormalized
+}
+
+// DepthDistance uses metric distance of object when available and fallback to bottom position else
+type DepthDistance struct{}
+
+func (d DepthDistance) Distance(o *events.Object) (float64, DistanceUnit) {
+	if o.DistanceInMm > 0 {
+		return float64(o.DistanceInMm), DistanceMm
+	}
+	return BottomDistance{}.Distance(o)
+}
+
+// sortByProximity returns a copy of objects sorted from the nearest to the farthest, proximity increases when object is near
+func sortByProximity(objects []*events.Object, proximity func(o *events.Object) float64) []*events.Object {
+	keys := make(map[*events.Object]float64, len(objects))
+	for _, o := range objects {
+		keys[o] = proximity(o)
+	}
+	sorted := make([]*events.Object, len(objects))
+	copy(sorted, objects)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return keys[sorted[i]] > keys[sorted[j]]
+	})
+	return sorted
+}
+
+// bottomProximity returns bottom position of object in image, from 0 far away to 1 near
+func bottomProximity(o *events.Object) float64 {
+	return float64(o.Bottom)
+}
@@ -0,0 +1,185 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+)
+
+func TestDepthDistance_Distance(t *testing.T) {
+	tests := []struct {
+		name     string
+		object   *events.Object
+		want     float64
+		wantUnit DistanceUnit
+	}{
+		{
+			name:     "without depth",
+			object:   &events.Object{Bottom: 0.5},
+			want:     0.5,
+			wantUnit: DistanceNormalized,
+		},
+		{
+			name:     "with depth",
+			object:   &events.Object{Bottom: 0.5, DistanceInMm: 1200},
+			want:     1200.,
+			wantUnit: DistanceMm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit := DepthDistance{}.Distance(tt.object)
+			if got != tt.want || unit != tt.wantUnit {
+				t.Errorf("Distance() = %v %v, want %v %v", got, unit, tt.want, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestBottomDistance_Distance(t *testing.T) {
+	got, unit := BottomDistance{}.Distance(&events.Object{Bottom: 0.5, DistanceInMm: 1200})
+	if got != 0.5 || unit != DistanceNormalized {
+		t.Errorf("Distance() = %v %v, want %v %v", got, unit, 0.5, DistanceNormalized)
+	}
+}
+
+func TestGridCorrector_AdjustFromObjectPosition_Distance(t *testing.T) {
+	metricGridMap, err := WithGridMap("test_data/config-mm.json")
+	if err != nil {
+		t.Fatalf("unable to load metric grid map: %v", err)
+	}
+	// Object on middle, far in image but near according depth
+	objectNearWithDepth := events.Object{Left: 0.4, Top: 0.1, Right: 0.6, Bottom: 0.2, Confidence: 0.9, DistanceInMm: 300}
+	objectFarWithDepth := events.Object{Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9, DistanceInMm: 5000}
+
+	tests := []struct {
+		name    string
+		options []OptionCorrector
+		object  *events.Object
+		want    float64
+	}{
+		{
+			name:    "metric grid map, object without depth",
+			options: []OptionCorrector{metricGridMap},
+			object:  &objectOnMiddleNear,
+			want:    1.,
+		},
+		{
+			name:    "metric grid map, object with depth",
+			options: []OptionCorrector{metricGridMap},
+			object:  &objectNearWithDepth,
+			want:    1.,
+		},
+		{
+			name:    "metric grid map, object beyond last distance step",
+			options: []OptionCorrector{metricGridMap},
+			object:  &objectFarWithDepth,
+			want:    0.,
+		},
+		{
+			name:   "without metric grid map, depth is ignored",
+			object: &objectNearWithDepth,
+			want:   0.,
+		},
+		{
+			name:    "bottom distance source",
+			options: []OptionCorrector{metricGridMap, WithDistanceSource(BottomDistance{})},
+			object:  &objectNearWithDepth,
+			want:    0.,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector(tt.options...)
+			if got := c.AdjustFromObjectPosition(0., []*events.Object{tt.object}); got != tt.want {
+				t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortByProximity(t *testing.T) {
+	objects := []*events.Object{&objectOnMiddleDistant, &objectOnRightNear, &objectOnLeftDistant}
+	got := sortByProximity(objects, bottomProximity)
+	want := []*events.Object{&objectOnRightNear, &objectOnMiddleDistant, &objectOnLeftDistant}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sortByProximity()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if objects[0] != &objectOnMiddleDistant {
+		t.Errorf("sortByProximity() must not modify input slice")
+	}
+}
+
+func TestGridCorrector_proximityOf(t *testing.T) {
+	metricGridMap, err := WithGridMap("test_data/config-mm.json")
+	if err != nil {
+		t.Fatalf("unable to load metric grid map: %v", err)
+	}
+	tests := []struct {
+		name    string
+		options []OptionCorrector
+		object  *events.Object
+		want    float64
+	}{
+		{
+			name:    "depth mapped on metric grid range",
+			options: []OptionCorrector{metricGridMap},
+			object:  &events.Object{Bottom: 0.2, DistanceInMm: 1500},
+			want:    0.5,
+		},
+		{
+			name:    "depth beyond last distance step",
+			options: []OptionCorrector{metricGridMap},
+			object:  &events.Object{Bottom: 0.9, DistanceInMm: 5000},
+			want:    0.,
+		},
+		{
+			name:    "object without depth",
+			options: []OptionCorrector{metricGridMap},
+			object:  &events.Object{Bottom: 0.7},
+			want:    0.7,
+		},
+		{
+			name:   "without metric grid map, depth is ignored",
+			object: &events.Object{Bottom: 0.7, DistanceInMm: 1500},
+			want:   0.7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector(tt.options...)
+			if got := c.proximityOf(tt.object); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("proximityOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridCorrector_sortByProximity_MixedDistances(t *testing.T) {
+	metricGridMap, err := WithGridMap("test_data/config-mm.json")
+	if err != nil {
+		t.Fatalf("unable to load metric grid map: %v", err)
+	}
+	c := NewGridCorrector(metricGridMap)
+	// Proximities are 0.8, 0.6, 0.5 and 0.2, whatever order of objects
+	near := &events.Object{Bottom: 0.1, DistanceInMm: 600}
+	middle := &events.Object{Bottom: 0.6}
+	far := &events.Object{Bottom: 0.9, DistanceInMm: 1500}
+	farthest := &events.Object{Bottom: 0.2}
+	want := []*events.Object{near, middle, far, farthest}
+
+	for _, objects := range [][]*events.Object{
+		{farthest, far, middle, near},
+		{middle, far, near, farthest},
+		{far, farthest, near, middle},
+	} {
+		got := sortByProximity(objects, c.proximityOf)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("sortByProximity(%v)[%d] = %v, want %v", objects, i, got[i], want[i])
+			}
+		}
+	}
+}
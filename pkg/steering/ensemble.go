@@ -0,0 +1,261 @@
+package steering
+
+import (
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fusion defines how steering of several models are combined
+type Fusion string
+
+const (
+	// FusionWeighted is the average of steering values weighted by confidence
+	FusionWeighted Fusion = "weighted"
+	// FusionMedian is the median of steering values
+	FusionMedian Fusion = "median"
+	// FusionMostConfident is the steering value with highest confidence
+	FusionMostConfident Fusion = "most-confident"
+)
+
+// emittedFramesSize is the count of fused frames to remember to drop late messages
+const emittedFramesSize = 16
+
+type OptionEnsemble func(e *Ensemble)
+
+// WithFusion defines how steering values of models are combined
+func WithFusion(f Fusion) OptionEnsemble {
+	return func(e *Ensemble) {
+		e.fusion = f
+	}
+}
+
+// WithEnsembleWindow defines max delay to wait steering of all models for a frame
+func WithEnsembleWindow(window time.Duration) OptionEnsemble {
+	return func(e *Ensemble) {
+		e.window = window
+	}
+}
+
+func NewEnsemble(sources []string, options ...OptionEnsemble) *Ensemble {
+	e := &Ensemble{
+		sources: sources,
+		fusion:  FusionWeighted,
+		window:  50 * time.Millisecond,
+		pending: make(map[string]*ensembleFrame),
+	}
+	for _, o := range options {
+		o(e)
+	}
+	return e
+}
+
+/*
+Ensemble fuses steering of several tflite models computed on the same frame.
+
+Steering messages are grouped by FrameRef. A frame is fused as soon as all sources have sent a steering value, or with
+available values once window is elapsed since first value of frame. Late messages of an already fused frame are
+dropped. Messages without FrameRef can't be grouped, they are returned as is.
+*/
+type Ensemble struct {
+	sources []string
+	fusion  Fusion
+	window  time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*ensembleFrame
+	emitted []emittedFrame
+}
+
+// emittedFrame is a fused frame remembered to drop late messages
+type emittedFrame struct {
+	key        string
+	receivedAt time.Time
+}
+
+type ensembleFrame struct {
+	key        string
+	frameRef   *events.FrameRef
+	values     map[string]*events.SteeringMessage
+	receivedAt time.Time
+}
+
+// Window returns max delay to wait steering of all models for a frame
+func (e *Ensemble) Window() time.Duration {
+	return e.window
+}
+
+// Add records steering of source and returns fused steering if all sources have sent a value for the frame
+func (e *Ensemble) Add(source string, msg *events.SteeringMessage, ts time.Time) (*events.SteeringMessage, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := frameKey(msg.GetFrameRef())
+	if key == "" {
+		zap.S().Debugf("ensemble: steering of source %v without frame reference, skip fusion", source)
+		return msg, true
+	}
+	for _, f := range e.emitted {
+		if f.key == key {
+			zap.S().Debugf("ensemble: drop late steering of source %v for frame %v", source, key)
+			return nil, false
+		}
+	}
+
+	frame, ok := e.pending[key]
+	if !ok {
+		frame = &ensembleFrame{
+			key:        key,
+			frameRef:   msg.GetFrameRef(),
+			values:     make(map[string]*events.SteeringMessage, len(e.sources)),
+			receivedAt: ts,
+		}
+		e.pending[key] = frame
+	}
+	frame.values[source] = msg
+
+	if len(frame.values) < len(e.sources) {
+		return nil, false
+	}
+	return e.emit(frame), true
+}
+
+// pendingFrame returns true if frame identified by key waits steering of other sources
+func (e *Ensemble) pendingFrame(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.pending[key]
+	return ok
+}
+
+// receivedAt returns reception time of first steering of a fused frame, zero time if frame isn't remembered
+func (e *Ensemble) receivedAt(key string) time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, f := range e.emitted {
+		if f.key == key {
+			return f.receivedAt
+		}
+	}
+	return time.Time{}
+}
+
+// Expire returns fused steering of frames waiting since more than window, ordered by reception
+func (e *Ensemble) Expire(ts time.Time) []*events.SteeringMessage {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var expired []*ensembleFrame
+	for _, frame := range e.pending {
+		if ts.Sub(frame.receivedAt) >= e.window {
+			expired = append(expired, frame)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].receivedAt.Before(expired[j].receivedAt)
+	})
+
+	result := make([]*events.SteeringMessage, 0, len(expired))
+	for _, frame := range expired {
+		zap.S().Debugf("ensemble: window elapsed for frame %v, fuse %d/%d sources", frame.key, len(frame.values), len(e.sources))
+		result = append(result, e.emit(frame))
+	}
+	return result
+}
+
+// emit removes frame from pending frames and returns fused steering, lock must be held
+func (e *Ensemble) emit(frame *ensembleFrame) *events.SteeringMessage {
+	delete(e.pending, frame.key)
+	e.emitted = append(e.emitted, emittedFrame{key: frame.key, receivedAt: frame.receivedAt})
+	if len(e.emitted) > emittedFramesSize {
+		e.emitted = e.emitted[1:]
+	}
+
+	steering, confidence := e.fuse(frame)
+	return &events.SteeringMessage{
+		Steering:   float32(steering),
+		Confidence: float32(confidence),
+		FrameRef:   frame.frameRef,
+	}
+}
+
+// fuse combines steering values of frame, lock must be held
+func (e *Ensemble) fuse(frame *ensembleFrame) (float64, float64) {
+	// Iterate on sources to keep a stable order
+	values := make([]float64, 0, len(frame.values))
+	confidences := make([]float64, 0, len(frame.values))
+	tags := make([]string, 0, len(frame.values))
+	for _, source := range e.sources {
+		msg, ok := frame.values[source]
+		if !ok {
+			continue
+		}
+		values = append(values, float64(msg.GetSteering()))
+		confidences = append(confidences, float64(msg.GetConfidence()))
+		tags = append(tags, fmt.Sprintf("%v=%.3f (confidence %.3f)", source, msg.GetSteering(), msg.GetConfidence()))
+	}
+
+	var steering, confidence float64
+	switch e.fusion {
+	case FusionMedian:
+		steering, confidence = median(values), mean(confidences)
+	case FusionMostConfident:
+		best := 0
+		for i, c := range confidences {
+			if c > confidences[best] {
+				best = i
+			}
+		}
+		steering, confidence = values[best], confidences[best]
+	default:
+		totalConfidence := 0.
+		for i, c := range confidences {
+			steering += c * values[i]
+			totalConfidence += c
+		}
+		if totalConfidence > 0. {
+			steering /= totalConfidence
+		} else {
+			steering = mean(values)
+		}
+		confidence = mean(confidences)
+	}
+	zap.S().Debugf("ensemble: frame %v fused by %v to %.3f (confidence %.3f) from %v", frame.key, e.fusion, steering, confidence, strings.Join(tags, ", "))
+	return steering, confidence
+}
+
+// frameKey identifies frame, empty for steering messages without frame reference
+func frameKey(frameRef *events.FrameRef) string {
+	if frameRef.GetId() == "" {
+		return ""
+	}
+	return frameRef.GetName() + "/" + frameRef.GetId()
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.
+	}
+	sum := 0.
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0.
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2.
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,123 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+	"time"
+)
+
+func steeringOfFrame(frameId string, steering, confidence float32) *events.SteeringMessage {
+	return &events.SteeringMessage{
+		Steering:   steering,
+		Confidence: confidence,
+		FrameRef:   &events.FrameRef{Name: "camera", Id: frameId},
+	}
+}
+
+func TestEnsemble_Fusion(t *testing.T) {
+	tests := []struct {
+		name           string
+		fusion         Fusion
+		values         []*events.SteeringMessage
+		want           float64
+		wantConfidence float64
+	}{
+		{
+			name:   "weighted",
+			fusion: FusionWeighted,
+			values: []*events.SteeringMessage{steeringOfFrame("1", 0.2, 0.75), steeringOfFrame("1", 0.6, 0.25), steeringOfFrame("1", -0.4, 0.)},
+			want:   0.3, wantConfidence: 1. / 3.,
+		},
+		{
+			name:   "weighted without confidence",
+			fusion: FusionWeighted,
+			values: []*events.SteeringMessage{steeringOfFrame("1", 0.2, 0.), steeringOfFrame("1", 0.6, 0.), steeringOfFrame("1", -0.5, 0.)},
+			want:   0.1, wantConfidence: 0.,
+		},
+		{
+			name:   "median",
+			fusion: FusionMedian,
+			values: []*events.SteeringMessage{steeringOfFrame("1", 0.2, 0.75), steeringOfFrame("1", 0.6, 0.25), steeringOfFrame("1", -0.5, 0.5)},
+			want:   0.2, wantConfidence: 0.5,
+		},
+		{
+			name:   "most confident",
+			fusion: FusionMostConfident,
+			values: []*events.SteeringMessage{steeringOfFrame("1", 0.2, 0.5), steeringOfFrame("1", 0.6, 0.25), steeringOfFrame("1", -0.5, 0.75)},
+			want:   -0.5, wantConfidence: 0.75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := []string{"model/a", "model/b", "model/c"}
+			e := NewEnsemble(sources, WithFusion(tt.fusion))
+			now := time.Now()
+			var got *events.SteeringMessage
+			for i, v := range tt.values {
+				var ok bool
+				got, ok = e.Add(sources[i], v, now)
+				if ok != (i == len(tt.values)-1) {
+					t.Fatalf("Add() of source %d = %v, want fusion only with last source", i, ok)
+				}
+			}
+			if math.Abs(float64(got.GetSteering())-tt.want) > 1e-6 {
+				t.Errorf("Add() steering = %v, want %v", got.GetSteering(), tt.want)
+			}
+			if math.Abs(float64(got.GetConfidence())-tt.wantConfidence) > 1e-6 {
+				t.Errorf("Add() confidence = %v, want %v", got.GetConfidence(), tt.wantConfidence)
+			}
+			if got.GetFrameRef().GetId() != "1" {
+				t.Errorf("Add() frame = %v, want %v", got.GetFrameRef(), "1")
+			}
+		})
+	}
+}
+
+func TestEnsemble_Window(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := NewEnsemble([]string{"model/a", "model/b"}, WithEnsembleWindow(50*time.Millisecond))
+
+	if _, ok := e.Add("model/a", steeringOfFrame("1", 0.2, 1.), start); ok {
+		t.Errorf("Add() fused frame with missing source")
+	}
+	if _, ok := e.Add("model/a", steeringOfFrame("2", 0.4, 1.), start.Add(20*time.Millisecond)); ok {
+		t.Errorf("Add() fused frame with missing source")
+	}
+	if got := e.Expire(start.Add(40 * time.Millisecond)); len(got) != 0 {
+		t.Errorf("Expire() = %v, want none before end of window", got)
+	}
+
+	got := e.Expire(start.Add(50 * time.Millisecond))
+	if len(got) != 1 || got[0].GetFrameRef().GetId() != "1" || got[0].GetSteering() != 0.2 {
+		t.Errorf("Expire() = %v, want frame 1 with available steering", got)
+	}
+
+	// Late message of fused frame is dropped
+	if _, ok := e.Add("model/b", steeringOfFrame("1", -0.2, 1.), start.Add(55*time.Millisecond)); ok {
+		t.Errorf("Add() fused late message")
+	}
+	if got := e.Expire(start.Add(time.Second)); len(got) != 1 || got[0].GetFrameRef().GetId() != "2" {
+		t.Errorf("Expire() = %v, want only frame 2", got)
+	}
+}
+
+func TestEnsemble_WithoutFrameRef(t *testing.T) {
+	e := NewEnsemble([]string{"model/a", "model/b"})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		for _, steering := range []float32{0.2, 0.4} {
+			// Messages without frame can't be grouped, they aren't merged
+			got, ok := e.Add("model/a", &events.SteeringMessage{Steering: steering, Confidence: 1.}, now)
+			if !ok {
+				t.Fatalf("Add() message %d without frame not returned", i)
+			}
+			if got.GetSteering() != steering {
+				t.Errorf("Add() = %v, want %v", got.GetSteering(), steering)
+			}
+		}
+	}
+	if got := e.Expire(now.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("Expire() = %v, want none pending frame", got)
+	}
+}
@@ -0,0 +1,49 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+)
+
+// ObjectsFilter selects objects to consider for steering correction
+type ObjectsFilter interface {
+	Filter(objects []*events.Object) []*events.Object
+}
+
+// ObjectsWeighting is implemented by objects filters that weight correction of kept objects
+type ObjectsWeighting interface {
+	// Weighting returns weight of an object in correction, from 0 to 1, nil if correction isn't weighted
+	Weighting() func(o *events.Object) float64
+}
+
+func NewConfidenceFilter(minConfidence float64, weighting bool) *ConfidenceFilter {
+	return &ConfidenceFilter{minConfidence: minConfidence, weighting: weighting}
+}
+
+// ConfidenceFilter drops objects with a confidence lower than threshold, and weights correction of kept objects by their
+// confidence if weighting is enabled
+type ConfidenceFilter struct {
+	minConfidence float64
+	weighting     bool
+}
+
+func (f *ConfidenceFilter) Filter(objects []*events.Object) []*events.Object {
+	res := make([]*events.Object, 0, len(objects))
+	for _, o := range objects {
+		if float64(o.GetConfidence()) < f.minConfidence {
+			zap.S().Debugf("drop object with low confidence: %v < %v", o.GetConfidence(), f.minConfidence)
+			continue
+		}
+		res = append(res, o)
+	}
+	return res
+}
+
+func (f *ConfidenceFilter) Weighting() func(o *events.Object) float64 {
+	if !f.weighting {
+		return nil
+	}
+	return func(o *events.Object) float64 {
+		return float64(o.GetConfidence())
+	}
+}
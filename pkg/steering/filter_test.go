@@ -0,0 +1,55 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestConfidenceFilter_Filter(t *testing.T) {
+	lowConfidence := events.Object{Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.1}
+	tests := []struct {
+		name          string
+		minConfidence float64
+		objects       []*events.Object
+		want          []*events.Object
+	}{
+		{
+			name:          "no objects",
+			minConfidence: 0.5,
+			objects:       []*events.Object{},
+			want:          []*events.Object{},
+		},
+		{
+			name:          "low confidence objects are dropped",
+			minConfidence: 0.5,
+			objects:       []*events.Object{&lowConfidence, &objectOnMiddleNear},
+			want:          []*events.Object{&objectOnMiddleNear},
+		},
+		{
+			name:          "without threshold",
+			minConfidence: 0.,
+			objects:       []*events.Object{&lowConfidence, &objectOnMiddleNear},
+			want:          []*events.Object{&lowConfidence, &objectOnMiddleNear},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewConfidenceFilter(tt.minConfidence, false)
+			if got := f.Filter(tt.objects); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidenceFilter_Weighting(t *testing.T) {
+	if w := NewConfidenceFilter(0.5, false).Weighting(); w != nil {
+		t.Errorf("Weighting() must be nil when weighting is disabled")
+	}
+	w := NewConfidenceFilter(0.5, true).Weighting()
+	if got := w(&events.Object{Confidence: 0.7}); math.Abs(got-0.7) > 1e-6 {
+		t.Errorf("Weighting()() = %v, want %v", got, 0.7)
+	}
+}
@@ -0,0 +1,71 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"sync"
+	"time"
+)
+
+func newObjectsBuffer(size int, tolerance time.Duration) *objectsBuffer {
+	return &objectsBuffer{
+		size:      size,
+		tolerance: tolerance,
+		snapshots: make([]objectsSnapshot, 0, size),
+	}
+}
+
+/*
+objectsBuffer keeps the last objects messages to find objects detected on the same frame as a steering message.
+*/
+type objectsBuffer struct {
+	mu        sync.RWMutex
+	size      int
+	tolerance time.Duration
+	// snapshots sorted by reception, the oldest first
+	snapshots []objectsSnapshot
+}
+
+func (b *objectsBuffer) add(s objectsSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.snapshots) >= b.size {
+		b.snapshots = b.snapshots[1:]
+	}
+	b.snapshots = append(b.snapshots, s)
+}
+
+/*
+match returns objects detected on frame, or on the closest earlier frame within tolerance. Returns false if no
+objects message matches frame.
+*/
+func (b *objectsBuffer) match(frame *events.FrameRef) ([]*events.Object, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if frame.GetId() != "" {
+		for i := len(b.snapshots) - 1; i >= 0; i-- {
+			if b.snapshots[i].frameId == frame.GetId() {
+				return b.snapshots[i].objects, true
+			}
+		}
+	}
+
+	if frame.GetCreatedAt() == nil {
+		return nil, false
+	}
+	createdAt := frame.GetCreatedAt().AsTime()
+	var closest *objectsSnapshot
+	for i := range b.snapshots {
+		s := &b.snapshots[i]
+		if s.createdAt.IsZero() || s.createdAt.After(createdAt) || createdAt.Sub(s.createdAt) > b.tolerance {
+			continue
+		}
+		if closest == nil || s.createdAt.After(closest.createdAt) {
+			closest = s
+		}
+	}
+	if closest == nil {
+		return nil, false
+	}
+	return closest.objects, true
+}
@@ -0,0 +1,111 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func Test_objectsBuffer_match(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	objects1 := []*events.Object{&objectOnLeftNear}
+	objects2 := []*events.Object{&objectOnMiddleNear}
+	objects3 := []*events.Object{&objectOnRightNear}
+
+	b := newObjectsBuffer(2, 100*time.Millisecond)
+	b.add(objectsSnapshot{objects: objects1, frameId: "01", createdAt: start})
+	b.add(objectsSnapshot{objects: objects2, frameId: "02", createdAt: start.Add(50 * time.Millisecond)})
+	b.add(objectsSnapshot{objects: objects3, frameId: "03", createdAt: start.Add(100 * time.Millisecond)})
+
+	tests := []struct {
+		name      string
+		frame     *events.FrameRef
+		want      []*events.Object
+		wantMatch bool
+	}{
+		{
+			name:      "same frame",
+			frame:     &events.FrameRef{Id: "02", CreatedAt: timestamppb.New(start.Add(50 * time.Millisecond))},
+			want:      objects2,
+			wantMatch: true,
+		},
+		{
+			name:      "same frame without timestamp",
+			frame:     &events.FrameRef{Id: "03"},
+			want:      objects3,
+			wantMatch: true,
+		},
+		{
+			name:      "evicted frame",
+			frame:     &events.FrameRef{Id: "01"},
+			wantMatch: false,
+		},
+		{
+			name:      "closest earlier frame",
+			frame:     &events.FrameRef{Id: "04", CreatedAt: timestamppb.New(start.Add(80 * time.Millisecond))},
+			want:      objects2,
+			wantMatch: true,
+		},
+		{
+			name:      "earlier frame out of tolerance",
+			frame:     &events.FrameRef{Id: "05", CreatedAt: timestamppb.New(start.Add(300 * time.Millisecond))},
+			wantMatch: false,
+		},
+		{
+			name:      "only later frames",
+			frame:     &events.FrameRef{Id: "00", CreatedAt: timestamppb.New(start.Add(10 * time.Millisecond))},
+			wantMatch: false,
+		},
+		{
+			name:      "without frame",
+			frame:     nil,
+			wantMatch: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := b.match(tt.frame)
+			if ok != tt.wantMatch {
+				t.Fatalf("match() ok = %v, want %v", ok, tt.wantMatch)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFrameSync(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		wantErr    bool
+	}{
+		{name: "valid size", bufferSize: 1, wantErr: false},
+		{name: "empty buffer", bufferSize: 0, wantErr: true},
+		{name: "negative size", bufferSize: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option, err := WithFrameSync(tt.bufferSize, 100*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithFrameSync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			c := &Controller{}
+			option(c)
+			// Buffer must accept more snapshots than its size, oldest snapshot is evicted
+			c.objectsBuffer.add(objectsSnapshot{frameId: "01", objects: []*events.Object{&objectOnLeftNear}})
+			c.objectsBuffer.add(objectsSnapshot{frameId: "02", objects: []*events.Object{&objectOnRightNear}})
+			if _, ok := c.objectsBuffer.match(&events.FrameRef{Id: "01"}); ok {
+				t.Errorf("oldest snapshot must be evicted")
+			}
+			if objects, ok := c.objectsBuffer.match(&events.FrameRef{Id: "02"}); !ok || len(objects) != 1 || objects[0] != &objectOnRightNear {
+				t.Errorf("last snapshot must be kept, got %v", objects)
+			}
+		})
+	}
+}
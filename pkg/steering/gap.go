@@ -0,0 +1,180 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+)
+
+type OptionGapCorrector func(c *GapCorrector)
+
+// WithLookahead defines height in image, between 0 (top) and 1 (bottom), where objects are projected
+func WithLookahead(l float64) OptionGapCorrector {
+	return func(c *GapCorrector) {
+		c.lookahead = l
+	}
+}
+
+// WithSafetyMargin defines margin to add on the left and right of each object, in image width percent
+func WithSafetyMargin(m float64) OptionGapCorrector {
+	return func(c *GapCorrector) {
+		c.safetyMargin = m
+	}
+}
+
+// WithMinCorridorWidth defines the minimal width, in image width percent, of a free corridor
+func WithMinCorridorWidth(w float64) OptionGapCorrector {
+	return func(c *GapCorrector) {
+		c.minCorridorWidth = w
+	}
+}
+
+func NewGapCorrector(options ...OptionGapCorrector) *GapCorrector {
+	c := &GapCorrector{
+		lookahead:        0.6,
+		safetyMargin:     0.05,
+		minCorridorWidth: 0.1,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+/*
+GapCorrector steers into free corridors between objects:
+
+ 1. objects whose bottom reaches lookahead height are projected on a 1-D occupancy line, each box is inflated by safety
+    margin
+ 2. free corridors narrower than minimal width are dropped
+ 3. current steering is kept if it targets a free corridor, at half minimal width from its borders at least
+ 4. else steering targets the nearest position that fulfills this condition in the closest corridor
+*/
+type GapCorrector struct {
+	lookahead        float64
+	safetyMargin     float64
+	minCorridorWidth float64
+}
+
+func (c *GapCorrector) AdjustFromObjectPosition(currentSteering float64, objs []*events.Object) float64 {
+	objects := make([]*events.Object, 0, len(objs))
+	for _, o := range objs {
+		if float64(o.Bottom) >= c.lookahead {
+			objects = append(objects, o)
+		}
+	}
+	zap.S().Debugf("%v/%v objects on lookahead line", len(objects), len(objs))
+	if len(objects) == 0 {
+		return currentSteering
+	}
+
+	corridors := make([]gap, 0, len(objects)+1)
+	for _, g := range freeGaps(objects, c.safetyMargin) {
+		if g.width() >= c.minCorridorWidth {
+			corridors = append(corridors, g)
+		}
+	}
+	if len(corridors) == 0 {
+		zap.S().Warnf("no free corridor found, skip correction")
+		return currentSteering
+	}
+
+	position := (currentSteering + 1.) / 2.
+	margin := c.minCorridorWidth / 2.
+	corridor := corridors[0]
+	target := corridor.target(position, margin)
+	for _, g := range corridors[1:] {
+		if t := g.target(position, margin); math.Abs(t-position) < math.Abs(target-position) {
+			corridor, target = g, t
+		}
+	}
+	if target == position {
+		zap.S().Debugf("current steering is into free corridor [%v, %v]", corridor.left, corridor.right)
+		return currentSteering
+	}
+	zap.S().Debugf("steer to free corridor [%v, %v]", corridor.left, corridor.right)
+	return target*2. - 1.
+}
+
+// minGapWidth ignores gaps due to float32 rounding of objects coordinates
+const minGapWidth = 1e-6
+
+// gap is a free horizontal segment, in image coordinates [0,1]
+type gap struct {
+	left, right float64
+}
+
+func (g gap) width() float64 {
+	return g.right - g.left
+}
+
+func (g gap) centre() float64 {
+	return (g.left + g.right) / 2.
+}
+
+// target returns the nearest position into gap, at margin from its borders, or its centre if gap is too narrow
+func (g gap) target(position, margin float64) float64 {
+	if g.width() <= 2*margin {
+		return g.centre()
+	}
+	return clamp(position, g.left+margin, g.right-margin)
+}
+
+// steering returns steering value that targets gap centre
+func (g gap) steering() float64 {
+	return g.centre()*2. - 1.
+}
+
+/*
+freeGaps projects objects on horizontal axis, inflated by margin on each side, and returns free segments between
+them, from left to right.
+*/
+func freeGaps(objects []*events.Object, margin float64) []gap {
+	occupied := make([]gap, 0, len(objects))
+	for _, o := range objects {
+		occupied = append(occupied, gap{
+			left:  clamp(float64(o.Left)-margin, 0., 1.),
+			right: clamp(float64(o.Right)+margin, 0., 1.),
+		})
+	}
+	sort.Slice(occupied, func(i, j int) bool {
+		return occupied[i].left < occupied[j].left
+	})
+
+	gaps := make([]gap, 0, len(occupied)+1)
+	position := 0.
+	for _, o := range occupied {
+		if o.left-position > minGapWidth {
+			gaps = append(gaps, gap{left: position, right: o.left})
+		}
+		if o.right > position {
+			position = o.right
+		}
+	}
+	if 1.-position > minGapWidth {
+		gaps = append(gaps, gap{left: position, right: 1.})
+	}
+	return gaps
+}
+
+// widestGap returns the widest gap, the first one wins on equality
+func widestGap(gaps []gap) gap {
+	widest := gaps[0]
+	for _, g := range gaps[1:] {
+		if g.width() > widest.width() {
+			widest = g
+		}
+	}
+	return widest
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
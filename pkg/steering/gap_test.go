@@ -0,0 +1,143 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+)
+
+func Test_freeGaps(t *testing.T) {
+	type args struct {
+		objects []*events.Object
+		margin  float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want []gap
+	}{
+		{
+			name: "no objects",
+			args: args{objects: []*events.Object{}},
+			want: []gap{{left: 0., right: 1.}},
+		},
+		{
+			name: "objects on left and right",
+			args: args{objects: []*events.Object{&objectOnRightNear, &objectOnLeftNear}},
+			want: []gap{{left: 0., right: 0.1}, {left: 0.3, right: 0.7}, {left: 0.9, right: 1.}},
+		},
+		{
+			name: "objects with margin",
+			args: args{objects: []*events.Object{&objectOnRightNear, &objectOnLeftNear}, margin: 0.1},
+			want: []gap{{left: 0.4, right: 0.6}},
+		},
+		{
+			name: "overlapped objects",
+			args: args{objects: []*events.Object{
+				{Left: 0.2, Right: 0.5},
+				{Left: 0.3, Right: 0.4},
+				{Left: 0.45, Right: 0.6},
+			}},
+			want: []gap{{left: 0., right: 0.2}, {left: 0.6, right: 1.}},
+		},
+		{
+			name: "all space is occupied",
+			args: args{objects: []*events.Object{{Left: -0.1, Right: 1.1}}},
+			want: []gap{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := freeGaps(tt.args.objects, tt.args.margin)
+			if len(got) != len(tt.want) {
+				t.Fatalf("freeGaps() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i].left-tt.want[i].left) > 1e-6 || math.Abs(got[i].right-tt.want[i].right) > 1e-6 {
+					t.Errorf("freeGaps() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGapCorrector_AdjustFromObjectPosition(t *testing.T) {
+	type args struct {
+		currentSteering float64
+		objects         []*events.Object
+	}
+	tests := []struct {
+		name    string
+		options []OptionGapCorrector
+		args    args
+		want    float64
+	}{
+		{
+			name: "run straight without objects",
+			args: args{currentSteering: 0., objects: []*events.Object{}},
+			want: 0.,
+		},
+		{
+			name: "distant objects are ignored",
+			args: args{currentSteering: 0.3, objects: []*events.Object{&objectOnMiddleDistant}},
+			want: 0.3,
+		},
+		{
+			name: "near object on side, keep straight steering",
+			args: args{currentSteering: 0., objects: []*events.Object{&objectOnRightNear}},
+			// corridors [0, 0.65], [0.95, 1] is too narrow
+			want: 0.,
+		},
+		{
+			name: "current steering into corridor is kept",
+			args: args{currentSteering: -0.5, objects: []*events.Object{&objectOnMiddleNear}},
+			want: -0.5,
+		},
+		{
+			name: "current steering too close to corridor border",
+			args: args{currentSteering: -0.35, objects: []*events.Object{&objectOnMiddleNear}},
+			// position 0.325 into corridor [0, 0.35], target 0.3 at half minimal width from border
+			want: -0.4,
+		},
+		{
+			name: "near object on middle, go to nearest side",
+			args: args{currentSteering: 0.1, objects: []*events.Object{&objectOnMiddleNear}},
+			// corridors [0, 0.35] and [0.65, 1], target 0.7 at half minimal width from border
+			want: 0.4,
+		},
+		{
+			name: "near objects on left and right, go to middle corridor",
+			args: args{currentSteering: -0.9, objects: []*events.Object{&objectOnLeftNear, &objectOnRightNear}},
+			// corridors [0.35, 0.65], others are too narrow
+			want: -0.2,
+		},
+		{
+			name:    "without margin, narrow corridors are used",
+			options: []OptionGapCorrector{WithSafetyMargin(0.)},
+			args:    args{currentSteering: -0.9, objects: []*events.Object{&objectOnLeftNear, &objectOnRightNear}},
+			// corridors [0, 0.1], [0.3, 0.7] and [0.9, 1]
+			want: -0.9,
+		},
+		{
+			name:    "lookahead far away",
+			options: []OptionGapCorrector{WithLookahead(0.1)},
+			args:    args{currentSteering: 0., objects: []*events.Object{&objectOnMiddleDistant, &objectOnRightNear}},
+			// corridors [0, 0.35], [0.95, 1] is too narrow
+			want: -0.4,
+		},
+		{
+			name:    "none corridor",
+			options: []OptionGapCorrector{WithMinCorridorWidth(0.5)},
+			args:    args{currentSteering: 0.2, objects: []*events.Object{&objectOnMiddleNear}},
+			want:    0.2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGapCorrector(tt.options...)
+			if got := c.AdjustFromObjectPosition(tt.args.currentSteering, tt.args.objects); math.Abs(got-tt.want) > 1e-5 {
+				t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package steering
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	correctionSizeBuckets = []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1., 2.}
+	latencyBuckets        = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5}
+)
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		messages:       make(map[string]uint64),
+		unmarshalErrs:  make(map[string]uint64),
+		correctionSize: newHistogram(correctionSizeBuckets),
+		latency:        newHistogram(latencyBuckets),
+	}
+}
+
+/*
+Metrics collects controller activity and exposes it with Prometheus text format. Recording methods can be called on a
+nil Metrics, to disable collection, but not WriteTo and ServeHTTP.
+*/
+type Metrics struct {
+	mu             sync.Mutex
+	messages       map[string]uint64
+	unmarshalErrs  map[string]uint64
+	corrections    uint64
+	correctionSize *histogram
+	latency        *histogram
+	unmatched      uint64
+	driveMode      events.DriveMode
+	objects        int
+}
+
+// MessageReceived counts a message received on topic
+func (m *Metrics) MessageReceived(topic string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[topic]++
+}
+
+// UnmarshalError counts an invalid message received on topic
+func (m *Metrics) UnmarshalError(topic string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unmarshalErrs[topic]++
+}
+
+// CorrectionApplied counts an objects correction that changes steering by delta
+func (m *Metrics) CorrectionApplied(delta float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.corrections++
+	m.correctionSize.observe(max(delta, -delta))
+}
+
+// Published records delay between reception of message and publication of steering
+func (m *Metrics) Published(latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency.observe(latency.Seconds())
+}
+
+// UnmatchedFrame counts a steering message without objects from matching frame, with frame synchronisation
+func (m *Metrics) UnmatchedFrame() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unmatched++
+}
+
+// DriveMode records current drive mode
+func (m *Metrics) DriveMode(mode events.DriveMode) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.driveMode = mode
+}
+
+// Objects records count of objects to avoid
+func (m *Metrics) Objects(count int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects = count
+}
+
+// WriteTo writes metrics with Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+
+	writeHeader(cw, "steering_messages_received_total", "counter", "Count of messages received by topic")
+	writeCounters(cw, "steering_messages_received_total", m.messages)
+	writeHeader(cw, "steering_unmarshal_errors_total", "counter", "Count of messages that can't be unmarshalled by topic")
+	writeCounters(cw, "steering_unmarshal_errors_total", m.unmarshalErrs)
+
+	writeHeader(cw, "steering_corrections_total", "counter", "Count of steering values changed by objects correction")
+	fmt.Fprintf(cw, "steering_corrections_total %d\n", m.corrections)
+	writeHeader(cw, "steering_correction_size", "histogram", "Absolute steering change applied by objects correction")
+	m.correctionSize.writeTo(cw, "steering_correction_size")
+
+	writeHeader(cw, "steering_publish_latency_seconds", "histogram", "Delay between reception of steering input and publication")
+	m.latency.writeTo(cw, "steering_publish_latency_seconds")
+
+	writeHeader(cw, "steering_unmatched_frames_total", "counter", "Count of steering values without objects from matching frame")
+	fmt.Fprintf(cw, "steering_unmatched_frames_total %d\n", m.unmatched)
+
+	writeHeader(cw, "steering_drive_mode", "gauge", "Current drive mode, 1 for active mode")
+	for _, mode := range []events.DriveMode{events.DriveMode_INVALID, events.DriveMode_USER, events.DriveMode_PILOT, events.DriveMode_COPILOT} {
+		active := 0
+		if mode == m.driveMode {
+			active = 1
+		}
+		fmt.Fprintf(cw, "steering_drive_mode{mode=\"%s\"} %d\n", mode.String(), active)
+	}
+
+	writeHeader(cw, "steering_objects", "gauge", "Count of objects to avoid in last objects message")
+	fmt.Fprintf(cw, "steering_objects %d\n", m.objects)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP exposes metrics to Prometheus
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := m.WriteTo(w); err != nil {
+		zap.S().Errorf("unable to write metrics: %v", err)
+	}
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeCounters(w io.Writer, name string, counters map[string]uint64) {
+	topics := make([]string, 0, len(counters))
+	for t := range counters {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	for _, t := range topics {
+		fmt.Fprintf(w, "%s{topic=\"%s\"} %d\n", name, escapeLabel(t), counters[t])
+	}
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelReplacer.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// histogram counts observations by upper bound, counts aren't cumulative
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) writeTo(w io.Writer, name string) {
+	cumulative := uint64(0)
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(b), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// countWriter counts written bytes and keeps first error
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
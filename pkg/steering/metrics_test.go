@@ -0,0 +1,121 @@
+package steering
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseMetrics returns samples of Prometheus text format indexed by name and labels
+func parseMetrics(t *testing.T, r io.Reader) map[string]string {
+	samples := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i < 0 {
+			t.Fatalf("invalid sample line: %q", line)
+		}
+		samples[line[:i]] = line[i+1:]
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unable to read metrics: %v", err)
+	}
+	return samples
+}
+
+func TestMetrics_WriteTo(t *testing.T) {
+	m := NewMetrics()
+	m.MessageReceived("topic/steering")
+	m.MessageReceived("topic/steering")
+	m.MessageReceived("topic/objects")
+	m.UnmarshalError("topic/\"bad\"")
+	m.CorrectionApplied(0.15)
+	m.CorrectionApplied(-0.6)
+	m.Published(3 * time.Millisecond)
+	m.UnmatchedFrame()
+	m.DriveMode(events.DriveMode_PILOT)
+	m.Objects(4)
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unable to write metrics: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("bad written size: %v, wants %v", n, buf.Len())
+	}
+	samples := parseMetrics(t, &buf)
+
+	tests := []struct {
+		sample string
+		want   string
+	}{
+		{sample: `steering_messages_received_total{topic="topic/steering"}`, want: "2"},
+		{sample: `steering_messages_received_total{topic="topic/objects"}`, want: "1"},
+		{sample: `steering_unmarshal_errors_total{topic="topic/\"bad\""}`, want: "1"},
+		{sample: `steering_corrections_total`, want: "2"},
+		{sample: `steering_correction_size_bucket{le="0.1"}`, want: "0"},
+		{sample: `steering_correction_size_bucket{le="0.2"}`, want: "1"},
+		{sample: `steering_correction_size_bucket{le="0.75"}`, want: "2"},
+		{sample: `steering_correction_size_bucket{le="+Inf"}`, want: "2"},
+		{sample: `steering_correction_size_sum`, want: "0.75"},
+		{sample: `steering_correction_size_count`, want: "2"},
+		{sample: `steering_publish_latency_seconds_bucket{le="0.0025"}`, want: "0"},
+		{sample: `steering_publish_latency_seconds_bucket{le="0.005"}`, want: "1"},
+		{sample: `steering_publish_latency_seconds_count`, want: "1"},
+		{sample: `steering_unmatched_frames_total`, want: "1"},
+		{sample: `steering_drive_mode{mode="PILOT"}`, want: "1"},
+		{sample: `steering_drive_mode{mode="USER"}`, want: "0"},
+		{sample: `steering_objects`, want: "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sample, func(t *testing.T) {
+			got, ok := samples[tt.sample]
+			if !ok {
+				t.Fatalf("sample not found in %v", samples)
+			}
+			if got != tt.want {
+				t.Errorf("bad value: %v, wants %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := NewMetrics()
+	m.MessageReceived("topic/steering")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("bad content type: %v", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "# TYPE steering_messages_received_total counter\n") {
+		t.Errorf("type of metric not found in %v", w.Body.String())
+	}
+	samples := parseMetrics(t, w.Body)
+	if got := samples[`steering_messages_received_total{topic="topic/steering"}`]; got != "1" {
+		t.Errorf("bad received messages: %v, wants 1", got)
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	m.MessageReceived("topic/steering")
+	m.UnmarshalError("topic/steering")
+	m.CorrectionApplied(0.5)
+	m.Published(time.Millisecond)
+	m.UnmatchedFrame()
+	m.DriveMode(events.DriveMode_USER)
+	m.Objects(1)
+}
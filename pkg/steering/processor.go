@@ -0,0 +1,370 @@
+package steering
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"os"
+	"time"
+)
+
+// Source identifies origin of steering value
+type Source string
+
+const (
+	// SourceRC is steering from radio command
+	SourceRC Source = "rc"
+	// SourceTF is steering from tflite model
+	SourceTF Source = "tf"
+	// SourceCopilot is radio command steering blended with tflite steering, for COPILOT drive mode
+	SourceCopilot Source = "copilot"
+)
+
+// Steering is a steering value processed by pipeline
+type Steering struct {
+	Value      float64
+	Confidence float64
+	Source     Source
+	DriveMode  events.DriveMode
+	Speed      Speed
+	FrameRef   *events.FrameRef
+	// Timestamp is frame creation time if available, reception time else
+	Timestamp time.Time
+	// ReceivedAt is reception time of message
+	ReceivedAt time.Time
+	// Correction is the steering change applied by objects correction
+	Correction float64
+	// Diagnosis explains objects correction, nil if corrector doesn't explain its correction
+	Diagnosis *Diagnosis
+
+	objects       func() []*events.Object
+	objectsCache  []*events.Object
+	objectsLoaded bool
+	// objectWeight weights objects correction, nil if correction isn't weighted
+	objectWeight func(o *events.Object) float64
+}
+
+// Objects returns objects to avoid, loaded on first call
+func (s *Steering) Objects() []*events.Object {
+	if !s.objectsLoaded {
+		if s.objects != nil {
+			s.objectsCache = s.objects()
+		}
+		s.objectsLoaded = true
+	}
+	return s.objectsCache
+}
+
+// loadedObjects returns objects loaded by pipeline, without loading them, nil if no processor needed objects
+func (s *Steering) loadedObjects() []*events.Object {
+	return s.objectsCache
+}
+
+// Processor is a stage of steering pipeline: input -> filters -> correctors -> limiters -> calibration -> publish
+type Processor interface {
+	Process(s *Steering)
+}
+
+// ProcessorFunc adapts a function to Processor interface
+type ProcessorFunc func(s *Steering)
+
+func (f ProcessorFunc) Process(s *Steering) {
+	f(s)
+}
+
+func NewCorrectorProcessor(c Corrector, onUserMode bool) *CorrectorProcessor {
+	return &CorrectorProcessor{corrector: c, onUserMode: onUserMode}
+}
+
+// CorrectorProcessor adjusts steering to avoid objects, steering from radio command is corrected only if onUserMode
+type CorrectorProcessor struct {
+	corrector  Corrector
+	onUserMode bool
+}
+
+func (p *CorrectorProcessor) Process(s *Steering) {
+	if s.Source == SourceRC && !p.onUserMode {
+		return
+	}
+	var value float64
+	switch corrector := p.corrector.(type) {
+	case SpeedAwareDiagnosticCorrector:
+		var diagnosis Diagnosis
+		value, diagnosis = corrector.DiagnoseWithSpeed(s.Value, s.Objects(), s.Speed)
+		s.Diagnosis = &diagnosis
+	case DiagnosticCorrector:
+		var diagnosis Diagnosis
+		value, diagnosis = corrector.Diagnose(s.Value, s.Objects())
+		s.Diagnosis = &diagnosis
+	case SpeedAwareCorrector:
+		value = corrector.AdjustWithSpeed(s.Value, s.Objects(), s.Speed)
+	default:
+		value = corrector.AdjustFromObjectPosition(s.Value, s.Objects())
+	}
+	if s.objectWeight != nil && value != s.Value {
+		value = s.Value + (value-s.Value)*p.weightOf(s)
+	}
+	zap.S().Debugf("adjust steering to avoid objects: %v -> %v", s.Value, value)
+	s.Correction += value - s.Value
+	s.Value = value
+}
+
+/*
+weightOf returns weight of objects correction: mean of objects weights, weighted by the correction of each object alone.
+Max weight is used if no object corrects steering alone.
+*/
+func (p *CorrectorProcessor) weightOf(s *Steering) float64 {
+	var sum, weighted, maxWeight float64
+	for _, o := range s.Objects() {
+		w := s.objectWeight(o)
+		maxWeight = math.Max(maxWeight, w)
+		delta := math.Abs(p.adjust(s, []*events.Object{o}) - s.Value)
+		sum += delta
+		weighted += w * delta
+	}
+	if sum == 0. {
+		return maxWeight
+	}
+	return weighted / sum
+}
+
+// adjust corrects steering for objects, without diagnosis
+func (p *CorrectorProcessor) adjust(s *Steering, objects []*events.Object) float64 {
+	if corrector, ok := p.corrector.(SpeedAwareCorrector); ok {
+		return corrector.AdjustWithSpeed(s.Value, objects, s.Speed)
+	}
+	return p.corrector.AdjustFromObjectPosition(s.Value, objects)
+}
+
+// OnObjects forwards objects to corrector if it needs all objects messages
+func (p *CorrectorProcessor) OnObjects(objects []*events.Object, ts time.Time) {
+	if observer, ok := p.corrector.(ObjectsObserver); ok {
+		observer.OnObjects(objects, ts)
+	}
+}
+
+func NewFilterProcessor(f SteeringFilter, onUserMode bool) *FilterProcessor {
+	return &FilterProcessor{filter: f, onUserMode: onUserMode}
+}
+
+/*
+FilterProcessor smooths steering with a SteeringFilter, steering from radio command is smoothed only if onUserMode.
+
+Filter is timed by reception of steering: frame creation time isn't available for radio command steering, and isn't
+comparable with reception time when source changes.
+*/
+type FilterProcessor struct {
+	filter     SteeringFilter
+	onUserMode bool
+}
+
+func (p *FilterProcessor) Process(s *Steering) {
+	if s.Source == SourceRC && !p.onUserMode {
+		return
+	}
+	value := p.filter.Apply(s.Value, s.ReceivedAt)
+	zap.S().Debugf("smooth steering: %v -> %v", s.Value, value)
+	s.Value = value
+}
+
+// ProcessorConfig declares a pipeline stage, only parameters of stage type are used
+type ProcessorConfig struct {
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// UserMode enables processing of radio command steering, for objects-correction, ema, slew-rate and one-euro
+	UserMode bool `json:"user_mode,omitempty"`
+	// Alpha is the weight of new value, for ema
+	Alpha float64 `json:"alpha,omitempty"`
+	// MaxRate is the max steering variation by second, for slew-rate
+	MaxRate float64 `json:"max_rate,omitempty"`
+	// MinCutoff, Beta and DCutoff configure one-euro
+	MinCutoff float64 `json:"min_cutoff,omitempty"`
+	Beta      float64 `json:"beta,omitempty"`
+	DCutoff   float64 `json:"d_cutoff,omitempty"`
+	// ImageWidth, OffsetGain, HeadingGain, Weight, MinRoadConfidence and FallbackConfidence configure
+	// road-correction, default values are used if not set
+	ImageWidth         int     `json:"image_width,omitempty"`
+	OffsetGain         float64 `json:"offset_gain,omitempty"`
+	HeadingGain        float64 `json:"heading_gain,omitempty"`
+	Weight             float64 `json:"weight,omitempty"`
+	MinRoadConfidence  float64 `json:"min_road_confidence,omitempty"`
+	FallbackConfidence float64 `json:"fallback_confidence,omitempty"`
+	// Threshold, Fallback, Blend and Decay configure arbitration, road parameters are used by road fallback
+	Threshold float64 `json:"threshold,omitempty"`
+	Fallback  string  `json:"fallback,omitempty"`
+	Blend     bool    `json:"blend,omitempty"`
+	Decay     string  `json:"decay,omitempty"`
+	// Duration configures transition
+	Duration string `json:"duration,omitempty"`
+	// Calibration configures calibration with LoadCalibration format, missing fields keep default values
+	Calibration json.RawMessage `json:"calibration,omitempty"`
+}
+
+type pipelineConfig struct {
+	Processors []ProcessorConfig `json:"processors"`
+}
+
+/*
+LoadProcessors builds pipeline declared in json file, stages are applied in order and disabled stages are skipped:
+
+	{
+	  "processors": [
+	    {"type": "arbitration", "threshold": 0.5, "fallback": "road", "blend": true, "decay": "1s"},
+	    {"type": "ema", "alpha": 0.5},
+	    {"type": "road-correction", "weight": 0.3, "fallback_confidence": 0.4},
+	    {"type": "objects-correction", "user_mode": false},
+	    {"type": "slew-rate", "max_rate": 4.0},
+	    {"type": "one-euro", "min_cutoff": 1.0, "beta": 0.1, "d_cutoff": 1.0, "disabled": true},
+	    {"type": "transition", "duration": "500ms"},
+	    {"type": "calibration", "calibration": {"trim": 0.05, "left_gain": 0.9}}
+	  ]
+	}
+
+Objects correction uses corrector. Drive mode transition and calibration, if not declared, are applied by controller after
+declared pipeline.
+*/
+func LoadProcessors(configPath string, corrector Corrector) ([]Processor, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read pipeline config from file '%v': %w", configPath, err)
+	}
+	var cfg pipelineConfig
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal json pipeline config '%s': %w", configPath, err)
+	}
+
+	processors := make([]Processor, 0, len(cfg.Processors))
+	var errs []error
+	for i, pc := range cfg.Processors {
+		if pc.Disabled {
+			zap.S().Infof("pipeline stage %d '%v' is disabled", i, pc.Type)
+			continue
+		}
+		p, err := newProcessor(pc, corrector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("processors[%d]: %w", i, err))
+			continue
+		}
+		processors = append(processors, p)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid json pipeline config '%s': %w", configPath, err)
+	}
+	return processors, nil
+}
+
+func newProcessor(cfg ProcessorConfig, corrector Corrector) (Processor, error) {
+	switch cfg.Type {
+	case "objects-correction":
+		if corrector == nil {
+			return nil, fmt.Errorf("objects-correction: no corrector defined")
+		}
+		return NewCorrectorProcessor(corrector, cfg.UserMode), nil
+	case "road-correction":
+		options, err := roadOptions(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("road-correction: %w", err)
+		}
+		return NewRoadCorrector(options...), nil
+	case "arbitration":
+		return newArbiter(cfg)
+	case "ema":
+		if cfg.Alpha <= 0. || cfg.Alpha > 1. {
+			return nil, fmt.Errorf("ema: alpha %v must be in ]0,1]", cfg.Alpha)
+		}
+		return NewFilterProcessor(NewEMAFilter(cfg.Alpha), cfg.UserMode), nil
+	case "slew-rate":
+		if cfg.MaxRate <= 0. {
+			return nil, fmt.Errorf("slew-rate: max_rate %v must be positive", cfg.MaxRate)
+		}
+		return NewFilterProcessor(NewSlewRateLimiter(cfg.MaxRate), cfg.UserMode), nil
+	case "one-euro":
+		if cfg.MinCutoff <= 0. || cfg.DCutoff <= 0. {
+			return nil, fmt.Errorf("one-euro: min_cutoff %v and d_cutoff %v must be positive", cfg.MinCutoff, cfg.DCutoff)
+		}
+		return NewFilterProcessor(NewOneEuroFilter(cfg.MinCutoff, cfg.Beta, cfg.DCutoff), cfg.UserMode), nil
+	case "transition":
+		duration, err := time.ParseDuration(cfg.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("transition: invalid duration '%v': %w", cfg.Duration, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("transition: duration %v must be positive", duration)
+		}
+		return NewTransition(duration), nil
+	case "calibration":
+		return newCalibration(cfg)
+	default:
+		return nil, fmt.Errorf("unknown processor type '%v'", cfg.Type)
+	}
+}
+
+func newCalibration(cfg ProcessorConfig) (Processor, error) {
+	c := DefaultCalibration()
+	if len(cfg.Calibration) > 0 {
+		if err := json.Unmarshal(cfg.Calibration, c); err != nil {
+			return nil, fmt.Errorf("calibration: unable to unmarshal calibration: %w", err)
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("calibration: %w", err)
+	}
+	return c, nil
+}
+
+func roadOptions(cfg ProcessorConfig) ([]OptionRoadCorrector, error) {
+	if cfg.ImageWidth < 0 {
+		return nil, fmt.Errorf("image_width %v must be positive", cfg.ImageWidth)
+	}
+	if cfg.Weight < 0. || cfg.Weight > 1. {
+		return nil, fmt.Errorf("weight %v must be in [0,1]", cfg.Weight)
+	}
+	var options []OptionRoadCorrector
+	if cfg.ImageWidth > 0 {
+		options = append(options, WithRoadImageWidth(cfg.ImageWidth))
+	}
+	if cfg.OffsetGain != 0. || cfg.HeadingGain != 0. {
+		options = append(options, WithRoadGains(cfg.OffsetGain, cfg.HeadingGain))
+	}
+	if cfg.Weight > 0. {
+		options = append(options, WithRoadWeight(cfg.Weight))
+	}
+	options = append(options,
+		WithRoadMinConfidence(cfg.MinRoadConfidence),
+		WithRoadFallback(cfg.FallbackConfidence),
+	)
+	return options, nil
+}
+
+func newArbiter(cfg ProcessorConfig) (Processor, error) {
+	if cfg.Threshold < 0. || cfg.Threshold > 1. {
+		return nil, fmt.Errorf("arbitration: threshold %v must be in [0,1]", cfg.Threshold)
+	}
+	options := []OptionArbiter{WithBlend(cfg.Blend)}
+	if cfg.Decay != "" {
+		decay, err := time.ParseDuration(cfg.Decay)
+		if err != nil {
+			return nil, fmt.Errorf("arbitration: invalid decay '%v': %w", cfg.Decay, err)
+		}
+		options = append(options, WithLastGoodDecay(decay))
+	}
+	switch Fallback(cfg.Fallback) {
+	case "":
+	case FallbackLastGood, FallbackStraight:
+		options = append(options, WithFallback(Fallback(cfg.Fallback)))
+	case FallbackRoad:
+		roadOpts, err := roadOptions(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("arbitration: %w", err)
+		}
+		options = append(options, WithFallback(FallbackRoad), WithFallbackRoad(NewRoadCorrector(roadOpts...)))
+	default:
+		return nil, fmt.Errorf("arbitration: unknown fallback '%v'", cfg.Fallback)
+	}
+	return NewArbiter(cfg.Threshold, options...), nil
+}
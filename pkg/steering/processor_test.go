@@ -0,0 +1,239 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordCorrector struct {
+	objects  []*events.Object
+	observed int
+}
+
+func (r *recordCorrector) AdjustFromObjectPosition(currentSteering float64, objects []*events.Object) float64 {
+	r.objects = objects
+	return currentSteering + 0.5
+}
+
+func (r *recordCorrector) OnObjects(_ []*events.Object, _ time.Time) {
+	r.observed += 1
+}
+
+func TestCorrectorProcessor_Process(t *testing.T) {
+	tests := []struct {
+		name        string
+		onUserMode  bool
+		source      Source
+		want        float64
+		wantObjects bool
+	}{
+		{name: "tf steering", onUserMode: false, source: SourceTF, want: 0.7, wantObjects: true},
+		{name: "rc steering without correction on user mode", onUserMode: false, source: SourceRC, want: 0.2, wantObjects: false},
+		{name: "rc steering with correction on user mode", onUserMode: true, source: SourceRC, want: 0.7, wantObjects: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := false
+			s := Steering{Value: 0.2, Source: tt.source, objects: func() []*events.Object {
+				loaded = true
+				return []*events.Object{&objectOnMiddleNear}
+			}}
+			c := recordCorrector{}
+			NewCorrectorProcessor(&c, tt.onUserMode).Process(&s)
+			if s.Value != tt.want {
+				t.Errorf("Process() = %v, want %v", s.Value, tt.want)
+			}
+			if loaded != tt.wantObjects {
+				t.Errorf("Process() objects loaded: %v, want %v", loaded, tt.wantObjects)
+			}
+			if tt.wantObjects && !reflect.DeepEqual(c.objects, []*events.Object{&objectOnMiddleNear}) {
+				t.Errorf("Process() corrector objects = %v, want %v", c.objects, []*events.Object{&objectOnMiddleNear})
+			}
+		})
+	}
+}
+
+func TestCorrectorProcessor_Process_ObjectWeight(t *testing.T) {
+	objectOnMiddleNearHalfConfidence := &events.Object{
+		Type:       events.TypeObject_ANY,
+		Left:       0.4,
+		Top:        0.8,
+		Right:      0.6,
+		Bottom:     0.9,
+		Confidence: 0.5,
+	}
+	confidence := func(o *events.Object) float64 {
+		return float64(o.GetConfidence())
+	}
+	tests := []struct {
+		name      string
+		corrector Corrector
+		objects   []*events.Object
+		weight    func(o *events.Object) float64
+		want      float64
+	}{
+		{
+			name:      "without weighting",
+			corrector: NewGridCorrector(),
+			objects:   []*events.Object{objectOnMiddleNearHalfConfidence},
+			want:      1.,
+		},
+		{
+			name:      "grid correction weighted",
+			corrector: NewGridCorrector(),
+			objects:   []*events.Object{objectOnMiddleNearHalfConfidence},
+			weight:    confidence,
+			want:      0.5,
+		},
+		{
+			name:      "gap correction weighted",
+			corrector: NewGapCorrector(),
+			objects:   []*events.Object{objectOnMiddleNearHalfConfidence},
+			weight:    confidence,
+			want:      0.5 * NewGapCorrector().AdjustFromObjectPosition(0., []*events.Object{&objectOnMiddleNear}),
+		},
+		{
+			name:      "weights averaged by correction of each object",
+			corrector: NewGridCorrector(),
+			objects:   []*events.Object{objectOnMiddleNearHalfConfidence, &objectOnMiddleNear},
+			weight:    confidence,
+			want:      (0.5 + 0.9) / 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Steering{Value: 0., Source: SourceTF, objectWeight: tt.weight, objects: func() []*events.Object {
+				return tt.objects
+			}}
+			NewCorrectorProcessor(tt.corrector, false).Process(&s)
+			if math.Abs(s.Value-tt.want) > 1e-6 {
+				t.Errorf("Process() = %v, want %v", s.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrectorProcessor_OnObjects(t *testing.T) {
+	c := recordCorrector{}
+	NewCorrectorProcessor(&c, false).OnObjects([]*events.Object{}, time.Now())
+	if c.observed != 1 {
+		t.Errorf("OnObjects() not forwarded to corrector")
+	}
+	// Correctors without observer are supported
+	NewCorrectorProcessor(NewGridCorrector(), false).OnObjects([]*events.Object{}, time.Now())
+}
+
+func TestFilterProcessor_Process(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := NewFilterProcessor(NewSlewRateLimiter(1.), false)
+	for i, step := range []struct {
+		offset time.Duration
+		source Source
+		value  float64
+		want   float64
+	}{
+		{offset: 0, source: SourceTF, value: 0., want: 0.},
+		{offset: 100 * time.Millisecond, source: SourceTF, value: 1., want: 0.1},
+		{offset: 150 * time.Millisecond, source: SourceRC, value: 1., want: 1.},
+		{offset: 200 * time.Millisecond, source: SourceTF, value: 1., want: 0.2},
+	} {
+		// Frame creation time of tflite steering is older than reception time of radio command steering
+		s := Steering{Value: step.value, Source: step.source, Timestamp: start, ReceivedAt: start.Add(step.offset)}
+		p.Process(&s)
+		if math.Abs(s.Value-step.want) > 1e-6 {
+			t.Errorf("step %d: Process() = %v, want %v", i, s.Value, step.want)
+		}
+	}
+}
+
+func TestFilterProcessor_Process_UserMode(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := NewFilterProcessor(NewSlewRateLimiter(1.), true)
+	for i, step := range []struct {
+		offset time.Duration
+		source Source
+		value  float64
+		want   float64
+	}{
+		{offset: 0, source: SourceRC, value: 0., want: 0.},
+		{offset: 100 * time.Millisecond, source: SourceRC, value: 1., want: 0.1},
+		// Source changes, filter isn't frozen
+		{offset: 200 * time.Millisecond, source: SourceTF, value: 1., want: 0.2},
+	} {
+		s := Steering{Value: step.value, Source: step.source, Timestamp: start.Add(-time.Second), ReceivedAt: start.Add(step.offset)}
+		if step.source == SourceRC {
+			s.Timestamp = s.ReceivedAt
+		}
+		p.Process(&s)
+		if math.Abs(s.Value-step.want) > 1e-6 {
+			t.Errorf("step %d: Process() = %v, want %v", i, s.Value, step.want)
+		}
+	}
+}
+
+func TestLoadProcessors(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		corrector Corrector
+		wantTypes []string
+		wantErrs  []string
+	}{
+		{
+			name:      "load config",
+			config:    "test_data/processors.json",
+			corrector: NewGridCorrector(),
+			wantTypes: []string{"*steering.Arbiter", "*steering.FilterProcessor", "*steering.RoadCorrector", "*steering.CorrectorProcessor", "*steering.FilterProcessor", "*steering.Transition", "*steering.Calibration"},
+		},
+		{
+			name:     "objects correction without corrector",
+			config:   "test_data/processors.json",
+			wantErrs: []string{"processors[3]: objects-correction: no corrector defined"},
+		},
+		{
+			name:   "invalid config",
+			config: "test_data/invalid-processors.json",
+			wantErrs: []string{
+				"processors[0]: ema: alpha 1.5 must be in ]0,1]",
+				"processors[1]: unknown processor type 'median'",
+				"processors[2]: slew-rate: max_rate 0 must be positive",
+				"processors[3]: road-correction: weight 1.2 must be in [0,1]",
+				"processors[4]: arbitration: unknown fallback 'unknown'",
+				"processors[5]: arbitration: invalid decay '1 second'",
+				"processors[6]: transition: duration 0s must be positive",
+				"processors[7]: transition: invalid duration ''",
+				"processors[8]: calibration: trim 1.5 must be in [-1,1]",
+			},
+		},
+		{
+			name:     "missing file",
+			config:   "test_data/missing.json",
+			wantErrs: []string{"unable to read pipeline config"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadProcessors(tt.config, tt.corrector)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Fatalf("LoadProcessors() error = %v, wantErrs %v", err, tt.wantErrs)
+			}
+			for _, e := range tt.wantErrs {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("LoadProcessors() error = %v, want contains '%v'", err, e)
+				}
+			}
+			if len(got) != len(tt.wantTypes) {
+				t.Fatalf("LoadProcessors() = %v, want %v", got, tt.wantTypes)
+			}
+			for i, p := range got {
+				if typ := reflect.TypeOf(p).String(); typ != tt.wantTypes[i] {
+					t.Errorf("LoadProcessors()[%d] = %v, want %v", i, typ, tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
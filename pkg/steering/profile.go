@@ -0,0 +1,145 @@
+package steering
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"os"
+)
+
+/*
+TypeProfile customizes correction for a type of object. Undefined grid maps fallback to ANY profile, then to
+GridCorrector configuration.
+*/
+type TypeProfile struct {
+	GridMap           *GridMap `json:"grid_map,omitempty"`
+	ObjectMoveFactors *GridMap `json:"object_move_factors,omitempty"`
+	// SafetyMargin enlarges object on left and right, in image width percent
+	SafetyMargin float64 `json:"safety_margin,omitempty"`
+	// Ignore disables correction for this type of object
+	Ignore bool `json:"ignore,omitempty"`
+}
+
+// TypeProfiles indexes profiles by object type
+type TypeProfiles map[events.TypeObject]*TypeProfile
+
+/*
+WithTypeProfiles loads profiles from json file indexed by type name:
+
+	{
+	  "PLOT": {"grid_map": {...}, "object_move_factors": {...}, "safety_margin": 0.05},
+	  "BUMP": {"ignore": true},
+	  "ANY": {"grid_map": {...}}
+	}
+*/
+func WithTypeProfiles(configPath string) (OptionCorrector, error) {
+	if configPath == "" {
+		return func(c *GridCorrector) {}, nil
+	}
+	profiles, err := loadTypeProfiles(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load objects profiles from file '%v': %w", configPath, err)
+	}
+	return func(c *GridCorrector) {
+		c.profiles = profiles
+	}, nil
+}
+
+func loadTypeProfiles(configPath string) (TypeProfiles, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read profiles from file '%v': %w", configPath, err)
+	}
+	var cfg map[string]*TypeProfile
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal json profiles '%s': %w", configPath, err)
+	}
+	profiles := make(TypeProfiles, len(cfg))
+	var errs []error
+	for name, p := range cfg {
+		t, ok := events.TypeObject_value[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown object type", name))
+			continue
+		}
+		if p == nil {
+			errs = append(errs, fmt.Errorf("%s: empty profile", name))
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		profiles[events.TypeObject(t)] = p
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid json profiles '%s': %w", configPath, err)
+	}
+	return profiles, nil
+}
+
+func (p *TypeProfile) Validate() error {
+	var errs []error
+	if p.GridMap != nil {
+		if err := p.GridMap.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("grid_map: %w", err))
+		}
+	}
+	if p.ObjectMoveFactors != nil {
+		if err := p.ObjectMoveFactors.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("object_move_factors: %w", err))
+		}
+	}
+	if p.SafetyMargin < 0 {
+		errs = append(errs, fmt.Errorf("safety_margin: %v, must be positive", p.SafetyMargin))
+	}
+	return errors.Join(errs...)
+}
+
+// profileOf returns profile of object type, fallback to ANY profile, nil if none is defined
+func (p TypeProfiles) profileOf(t events.TypeObject) *TypeProfile {
+	if profile, ok := p[t]; ok {
+		return profile
+	}
+	return p[events.TypeObject_ANY]
+}
+
+// gridMapOf returns grid map of object type, fallback to ANY profile, nil if none is defined
+func (p TypeProfiles) gridMapOf(t events.TypeObject) *GridMap {
+	if profile, ok := p[t]; ok && profile.GridMap != nil {
+		return profile.GridMap
+	}
+	if profile, ok := p[events.TypeObject_ANY]; ok {
+		return profile.GridMap
+	}
+	return nil
+}
+
+// objectMoveFactorsOf returns objects move factors of object type, fallback to ANY profile, nil if none is defined
+func (p TypeProfiles) objectMoveFactorsOf(t events.TypeObject) *GridMap {
+	if profile, ok := p[t]; ok && profile.ObjectMoveFactors != nil {
+		return profile.ObjectMoveFactors
+	}
+	if profile, ok := p[events.TypeObject_ANY]; ok {
+		return profile.ObjectMoveFactors
+	}
+	return nil
+}
+
+// withInterpolation returns a copy of profiles whose grid maps use interpolation mode i
+func (p TypeProfiles) withInterpolation(i Interpolation) TypeProfiles {
+	profiles := make(TypeProfiles, len(p))
+	for t, profile := range p {
+		cpy := *profile
+		if cpy.GridMap != nil {
+			cpy.GridMap = cpy.GridMap.WithInterpolation(i)
+		}
+		if cpy.ObjectMoveFactors != nil {
+			cpy.ObjectMoveFactors = cpy.ObjectMoveFactors.WithInterpolation(i)
+		}
+		profiles[t] = &cpy
+	}
+	return profiles
+}
@@ -0,0 +1,113 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"strings"
+	"testing"
+)
+
+func TestWithTypeProfiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantTypes []events.TypeObject
+		wantErrs  []string
+	}{
+		{
+			name:   "no config",
+			config: "",
+		},
+		{
+			name:      "load config",
+			config:    "test_data/profiles.json",
+			wantTypes: []events.TypeObject{events.TypeObject_ANY, events.TypeObject_BUMP, events.TypeObject_PLOT},
+		},
+		{
+			name:   "invalid config",
+			config: "test_data/invalid-profiles.json",
+			wantErrs: []string{
+				"TRUCK: unknown object type",
+				"CAR: grid_map: data row 0: 2 columns, want 1",
+				"safety_margin: -0.1, must be positive",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := GridCorrector{}
+			got, err := WithTypeProfiles(tt.config)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Fatalf("WithTypeProfiles() error = %v, wantErrs %v", err, tt.wantErrs)
+			}
+			for _, e := range tt.wantErrs {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("WithTypeProfiles() error = %v, want contains '%v'", err, e)
+				}
+			}
+			if err != nil {
+				return
+			}
+			got(&c)
+			if len(c.profiles) != len(tt.wantTypes) {
+				t.Errorf("WithTypeProfiles() = %v, want types %v", c.profiles, tt.wantTypes)
+			}
+			for _, typ := range tt.wantTypes {
+				if _, ok := c.profiles[typ]; !ok {
+					t.Errorf("WithTypeProfiles(), missing profile for %v", typ)
+				}
+			}
+		})
+	}
+}
+
+func TestGridCorrector_AdjustFromObjectPosition_Profiles(t *testing.T) {
+	profiles, err := WithTypeProfiles("test_data/profiles.json")
+	if err != nil {
+		t.Fatalf("unable to load profiles: %v", err)
+	}
+	// Object on middle right, at 65% of image height
+	objectAt := func(typ events.TypeObject) *events.Object {
+		return &events.Object{Type: typ, Left: 0.5, Top: 0.5, Right: 0.7, Bottom: 0.65, Confidence: 0.9}
+	}
+	tests := []struct {
+		name   string
+		object *events.Object
+		want   float64
+	}{
+		{
+			name:   "bump is ignored",
+			object: objectAt(events.TypeObject_BUMP),
+			want:   0.,
+		},
+		{
+			name:   "plot uses its own grid map",
+			object: objectAt(events.TypeObject_PLOT),
+			want:   -1.,
+		},
+		{
+			name:   "car fallback to ANY profile, enlarged by safety margin",
+			object: objectAt(events.TypeObject_CAR),
+			// Left: 0.45 -> steering -0.1 -> third column
+			want: 0.5,
+		},
+		{
+			name:   "any profile",
+			object: objectAt(events.TypeObject_ANY),
+			want:   0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGridCorrector(profiles)
+			if got := c.AdjustFromObjectPosition(0., []*events.Object{tt.object}); got != tt.want {
+				t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Without safety margin, object is on the fourth column
+	c := NewGridCorrector()
+	if got := c.AdjustFromObjectPosition(0., []*events.Object{objectAt(events.TypeObject_CAR)}); got != -0.5 {
+		t.Errorf("AdjustFromObjectPosition() without profiles = %v, want %v", got, -0.5)
+	}
+}
@@ -0,0 +1,217 @@
+package steering
+
+import (
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ReplaySource defines steering of records to replay
+type ReplaySource string
+
+const (
+	// ReplayAuto replays radio command steering on user drive mode and autopilot steering else
+	ReplayAuto ReplaySource = "auto"
+	// ReplayRC replays radio command steering
+	ReplayRC ReplaySource = "rc"
+	// ReplayTF replays autopilot steering
+	ReplayTF ReplaySource = "tf"
+)
+
+// Record is a recorded frame with steering values and objects detected on this frame
+type Record struct {
+	FrameRef          *events.FrameRef
+	Timestamp         time.Time
+	DriveMode         events.DriveMode
+	Steering          *events.SteeringMessage
+	AutopilotSteering *events.SteeringMessage
+	// Objects is nil if no objects have been recorded before frame
+	Objects *events.ObjectsMessage
+}
+
+// ReplayResult is the corrected steering of a record next to the original one
+type ReplayResult struct {
+	FrameId   string    `json:"frame_id"`
+	Timestamp time.Time `json:"timestamp"`
+	DriveMode string    `json:"drive_mode"`
+	Source    Source    `json:"source"`
+	Objects   int       `json:"objects"`
+	Original  float64   `json:"original"`
+	Corrected float64   `json:"corrected"`
+}
+
+/*
+LoadRecords reads protobuf RecordMessage files of recordsDir and ObjectsMessage files of objectsDir, objectsDir is
+optional.
+
+Records are sorted by frame creation time, with file name order for frames without creation time. Each record gets
+objects detected on the same frame, or last objects detected before it when frame has no objects.
+*/
+func LoadRecords(recordsDir, objectsDir string) ([]*Record, error) {
+	var records []*Record
+	err := readMessages(recordsDir, func() proto.Message { return &events.RecordMessage{} }, func(msg proto.Message) {
+		rm := msg.(*events.RecordMessage)
+		records = append(records, &Record{
+			FrameRef:          rm.GetFrame().GetId(),
+			Timestamp:         timestampOf(rm.GetFrame().GetId()),
+			DriveMode:         rm.GetDriveMode().GetDriveMode(),
+			Steering:          rm.GetSteering(),
+			AutopilotSteering: rm.GetAutopilotSteering(),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load records: %w", err)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	if objectsDir == "" {
+		return records, nil
+	}
+	var objects []*events.ObjectsMessage
+	err = readMessages(objectsDir, func() proto.Message { return &events.ObjectsMessage{} }, func(msg proto.Message) {
+		objects = append(objects, msg.(*events.ObjectsMessage))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load objects: %w", err)
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		return timestampOf(objects[i].GetFrameRef()).Before(timestampOf(objects[j].GetFrameRef()))
+	})
+	byFrame := make(map[string]*events.ObjectsMessage, len(objects))
+	for _, o := range objects {
+		if id := o.GetFrameRef().GetId(); id != "" {
+			byFrame[id] = o
+		}
+	}
+
+	next := 0
+	var last *events.ObjectsMessage
+	for _, r := range records {
+		for next < len(objects) && !timestampOf(objects[next].GetFrameRef()).After(r.Timestamp) {
+			last = objects[next]
+			next++
+		}
+		if o, ok := byFrame[r.FrameRef.GetId()]; ok && r.FrameRef.GetId() != "" {
+			r.Objects = o
+			continue
+		}
+		r.Objects = last
+	}
+	return records, nil
+}
+
+// readMessages unmarshals each regular file of dir, in name order
+func readMessages(dir string, newMsg func() proto.Message, onMsg func(proto.Message)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("unable to list files of '%v': %w", dir, err)
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read '%v': %w", path, err)
+		}
+		msg := newMsg()
+		if err := proto.Unmarshal(content, msg); err != nil {
+			return fmt.Errorf("unable to unmarshal '%v': %w", path, err)
+		}
+		onMsg(msg)
+	}
+	return nil
+}
+
+func timestampOf(frameRef *events.FrameRef) time.Time {
+	if frameRef.GetCreatedAt() == nil {
+		return time.Time{}
+	}
+	return frameRef.GetCreatedAt().AsTime()
+}
+
+/*
+Replay runs steering of records through processors pipeline, offline. Objects are filtered, if filter isn't nil, and sent
+to ObjectsObserver processors like Controller does, records without steering for source are skipped.
+*/
+func Replay(records []*Record, source ReplaySource, processors []Processor, filter ObjectsFilter) []*ReplayResult {
+	var objectWeight func(o *events.Object) float64
+	if w, ok := filter.(ObjectsWeighting); ok {
+		objectWeight = w.Weighting()
+	}
+
+	results := make([]*ReplayResult, 0, len(records))
+	var lastObjects *events.ObjectsMessage
+	var filtered []*events.Object
+	for _, r := range records {
+		msg, src := r.steeringOf(source)
+		if msg == nil {
+			continue
+		}
+
+		if r.Objects != nil && r.Objects != lastObjects {
+			lastObjects = r.Objects
+			filtered = r.Objects.GetObjects()
+			if filter != nil {
+				filtered = filter.Filter(filtered)
+			}
+			for _, p := range processors {
+				if observer, ok := p.(ObjectsObserver); ok {
+					observer.OnObjects(filtered, r.Timestamp)
+				}
+			}
+		}
+
+		var objects []*events.Object
+		if r.Objects != nil {
+			objects = filtered
+		}
+		s := &Steering{
+			Value:      float64(msg.GetSteering()),
+			Confidence: float64(msg.GetConfidence()),
+			Source:     src,
+			DriveMode:  r.DriveMode,
+			FrameRef:   r.FrameRef,
+			Timestamp:  r.Timestamp,
+			ReceivedAt: r.Timestamp,
+			objects: func() []*events.Object {
+				return objects
+			},
+			objectWeight: objectWeight,
+		}
+		for _, p := range processors {
+			p.Process(s)
+		}
+		results = append(results, &ReplayResult{
+			FrameId:   r.FrameRef.GetId(),
+			Timestamp: r.Timestamp,
+			DriveMode: r.DriveMode.String(),
+			Source:    src,
+			Objects:   len(objects),
+			Original:  float64(msg.GetSteering()),
+			Corrected: s.Value,
+		})
+	}
+	return results
+}
+
+// steeringOf returns steering of record to replay and its source, nil if missing
+func (r *Record) steeringOf(source ReplaySource) (*events.SteeringMessage, Source) {
+	switch source {
+	case ReplayRC:
+		return r.Steering, SourceRC
+	case ReplayTF:
+		return r.AutopilotSteering, SourceTF
+	}
+	if r.DriveMode == events.DriveMode_PILOT && r.AutopilotSteering != nil {
+		return r.AutopilotSteering, SourceTF
+	}
+	return r.Steering, SourceRC
+}
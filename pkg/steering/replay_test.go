@@ -0,0 +1,209 @@
+package steering
+
+import (
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var replayStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func frameRefAt(id string, delay time.Duration) *events.FrameRef {
+	return &events.FrameRef{Name: "camera", Id: id, CreatedAt: timestamppb.New(replayStart.Add(delay))}
+}
+
+func writeMessages(t *testing.T, messages ...proto.Message) string {
+	dir := t.TempDir()
+	for i, msg := range messages {
+		content, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatalf("unable to marshal message: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("msg-%03d.pb", i)), content, 0644); err != nil {
+			t.Fatalf("unable to write message: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestLoadRecords(t *testing.T) {
+	// Files aren't written in frame order
+	recordsDir := writeMessages(t,
+		&events.RecordMessage{
+			Frame:     &events.FrameMessage{Id: frameRefAt("2", 100*time.Millisecond)},
+			Steering:  &events.SteeringMessage{Steering: 0.2},
+			DriveMode: &events.DriveModeMessage{DriveMode: events.DriveMode_USER},
+		},
+		&events.RecordMessage{
+			Frame:     &events.FrameMessage{Id: frameRefAt("1", 0)},
+			Steering:  &events.SteeringMessage{Steering: 0.1},
+			DriveMode: &events.DriveModeMessage{DriveMode: events.DriveMode_USER},
+		},
+		&events.RecordMessage{
+			Frame:             &events.FrameMessage{Id: frameRefAt("3", 200*time.Millisecond)},
+			Steering:          &events.SteeringMessage{Steering: 0.3},
+			AutopilotSteering: &events.SteeringMessage{Steering: -0.3},
+			DriveMode:         &events.DriveModeMessage{DriveMode: events.DriveMode_PILOT},
+		},
+	)
+	objectsOfFrame2 := &events.ObjectsMessage{
+		Objects:  []*events.Object{{Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9}},
+		FrameRef: frameRefAt("2", 100*time.Millisecond),
+	}
+	objectsDir := writeMessages(t, objectsOfFrame2)
+
+	records, err := LoadRecords(recordsDir, objectsDir)
+	if err != nil {
+		t.Fatalf("unable to load records: %v", err)
+	}
+
+	tests := []struct {
+		frameId     string
+		steering    float32
+		wantObjects int
+	}{
+		{frameId: "1", steering: 0.1, wantObjects: 0},
+		{frameId: "2", steering: 0.2, wantObjects: 1},
+		{frameId: "3", steering: 0.3, wantObjects: 1},
+	}
+	if len(records) != len(tests) {
+		t.Fatalf("bad records count: %v, wants %v", len(records), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.frameId, func(t *testing.T) {
+			r := records[i]
+			if r.FrameRef.GetId() != tt.frameId {
+				t.Errorf("bad frame: %v, wants %v", r.FrameRef.GetId(), tt.frameId)
+			}
+			if r.Steering.GetSteering() != tt.steering {
+				t.Errorf("bad steering: %v, wants %v", r.Steering.GetSteering(), tt.steering)
+			}
+			if got := len(r.Objects.GetObjects()); got != tt.wantObjects {
+				t.Errorf("bad objects count: %v, wants %v", got, tt.wantObjects)
+			}
+		})
+	}
+}
+
+func TestLoadRecords_InvalidDir(t *testing.T) {
+	if _, err := LoadRecords(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Errorf("an error is expected for missing directory")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	objects := &events.ObjectsMessage{Objects: []*events.Object{{Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.9}}}
+	records := []*Record{
+		{
+			FrameRef:  frameRefAt("1", 0),
+			DriveMode: events.DriveMode_USER,
+			Steering:  &events.SteeringMessage{Steering: 0.1},
+		},
+		{
+			FrameRef:          frameRefAt("2", 100*time.Millisecond),
+			DriveMode:         events.DriveMode_PILOT,
+			Steering:          &events.SteeringMessage{Steering: 0.2},
+			AutopilotSteering: &events.SteeringMessage{Steering: -0.2},
+			Objects:           objects,
+		},
+		{
+			FrameRef:  frameRefAt("3", 200*time.Millisecond),
+			DriveMode: events.DriveMode_PILOT,
+			Steering:  &events.SteeringMessage{Steering: 0.3},
+			Objects:   objects,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		source        ReplaySource
+		wantSources   []Source
+		wantOriginal  []float64
+		wantCorrected []float64
+		wantObserved  int
+	}{
+		{
+			name:          "auto follows drive mode",
+			source:        ReplayAuto,
+			wantSources:   []Source{SourceRC, SourceTF, SourceRC},
+			wantOriginal:  []float64{0.1, -0.2, 0.3},
+			wantCorrected: []float64{0.1, 0.3, 0.3},
+			wantObserved:  1,
+		},
+		{
+			name:          "rc steering only",
+			source:        ReplayRC,
+			wantSources:   []Source{SourceRC, SourceRC, SourceRC},
+			wantOriginal:  []float64{0.1, 0.2, 0.3},
+			wantCorrected: []float64{0.1, 0.2, 0.3},
+			wantObserved:  1,
+		},
+		{
+			name:          "records without autopilot steering are skipped",
+			source:        ReplayTF,
+			wantSources:   []Source{SourceTF},
+			wantOriginal:  []float64{-0.2},
+			wantCorrected: []float64{0.3},
+			wantObserved:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrector := &recordCorrector{}
+			results := Replay(records, tt.source, []Processor{NewCorrectorProcessor(corrector, false)}, nil)
+
+			if len(results) != len(tt.wantSources) {
+				t.Fatalf("bad results count: %v, wants %v", len(results), len(tt.wantSources))
+			}
+			for i, r := range results {
+				if r.Source != tt.wantSources[i] {
+					t.Errorf("result %d: bad source: %v, wants %v", i, r.Source, tt.wantSources[i])
+				}
+				if math.Abs(r.Original-tt.wantOriginal[i]) > 1e-6 {
+					t.Errorf("result %d: bad original steering: %v, wants %v", i, r.Original, tt.wantOriginal[i])
+				}
+				if math.Abs(r.Corrected-tt.wantCorrected[i]) > 1e-6 {
+					t.Errorf("result %d: bad corrected steering: %v, wants %v", i, r.Corrected, tt.wantCorrected[i])
+				}
+			}
+			if corrector.observed != tt.wantObserved {
+				t.Errorf("bad count of objects notifications: %v, wants %v", corrector.observed, tt.wantObserved)
+			}
+		})
+	}
+}
+
+func TestReplay_ObjectsFilter(t *testing.T) {
+	lowConfidence := &events.Object{Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 0.9, Confidence: 0.1}
+	records := []*Record{
+		{
+			FrameRef:          frameRefAt("1", 0),
+			DriveMode:         events.DriveMode_PILOT,
+			AutopilotSteering: &events.SteeringMessage{Steering: 0.},
+			Objects:           &events.ObjectsMessage{Objects: []*events.Object{lowConfidence, &objectOnMiddleNear}},
+		},
+	}
+
+	corrector := &recordCorrector{}
+	results := Replay(records, ReplayTF, []Processor{NewCorrectorProcessor(corrector, false)}, NewConfidenceFilter(0.5, true))
+	if !reflect.DeepEqual(corrector.objects, []*events.Object{&objectOnMiddleNear}) {
+		t.Errorf("bad corrected objects: %v, wants %v", corrector.objects, []*events.Object{&objectOnMiddleNear})
+	}
+	if len(results) != 1 || results[0].Objects != 1 {
+		t.Fatalf("bad results: %v", results)
+	}
+	// Correction of recordCorrector is weighted by confidence of the kept object
+	if math.Abs(results[0].Corrected-0.5*0.9) > 1e-6 {
+		t.Errorf("bad corrected steering: %v, wants %v", results[0].Corrected, 0.5*0.9)
+	}
+	if len(records[0].Objects.GetObjects()) != 2 {
+		t.Errorf("records must not be modified by filter")
+	}
+}
@@ -0,0 +1,194 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+// RoadObserver is implemented by pipeline stages that need road messages
+type RoadObserver interface {
+	OnRoad(road *events.RoadMessage, ts time.Time)
+}
+
+type OptionRoadCorrector func(c *RoadCorrector)
+
+// WithRoadImageWidth defines width in pixels of images used to detect road
+func WithRoadImageWidth(width int) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.imageWidth = width
+	}
+}
+
+// WithRoadGains defines factors to apply on lateral offset, in [-1,1], and heading error, in [-1,1] for [-90°,90°]
+func WithRoadGains(offsetGain, headingGain float64) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.offsetGain = offsetGain
+		c.headingGain = headingGain
+	}
+}
+
+// WithRoadWeight defines weight of lane-keeping steering blended into tflite steering, between 0 and 1
+func WithRoadWeight(weight float64) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.weight = weight
+	}
+}
+
+// WithRoadMinConfidence defines minimal ellipse confidence to use road
+func WithRoadMinConfidence(confidence float64) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.minRoadConfidence = confidence
+	}
+}
+
+// WithRoadFallback replaces tflite steering by lane-keeping steering when model confidence is below minConfidence
+func WithRoadFallback(minConfidence float64) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.fallbackConfidence = minConfidence
+	}
+}
+
+// WithRoadTTL defines delay after which road is ignored if no new road is received
+func WithRoadTTL(ttl time.Duration) OptionRoadCorrector {
+	return func(c *RoadCorrector) {
+		c.ttl = ttl
+	}
+}
+
+func NewRoadCorrector(options ...OptionRoadCorrector) *RoadCorrector {
+	c := &RoadCorrector{
+		imageWidth:  160,
+		offsetGain:  1.,
+		headingGain: 1.,
+		weight:      0.3,
+		ttl:         500 * time.Millisecond,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+/*
+RoadCorrector blends a lane-keeping steering, computed from ellipse fitted on road, into tflite steering.
+
+Lateral offset is the horizontal position of ellipse centre, -1 on left border and 1 on right border of image. Heading
+error is the angle between major axis of ellipse and vertical axis, -1 for -90° (road to the left) and 1 for 90°.
+Lane-keeping steering is offsetGain * offset + headingGain * heading.
+
+Radio command steering is not corrected.
+*/
+type RoadCorrector struct {
+	imageWidth         int
+	offsetGain         float64
+	headingGain        float64
+	weight             float64
+	minRoadConfidence  float64
+	fallbackConfidence float64
+	ttl                time.Duration
+
+	mu   sync.RWMutex
+	road *roadEstimate
+}
+
+type roadEstimate struct {
+	offset     float64
+	heading    float64
+	confidence float64
+	ts         time.Time
+}
+
+func (c *RoadCorrector) OnRoad(road *events.RoadMessage, ts time.Time) {
+	ellipse := road.GetEllipse()
+	if ellipse == nil || ellipse.GetCenter() == nil {
+		zap.S().Debugf("road without ellipse, ignore it")
+		return
+	}
+
+	estimate := roadEstimate{
+		offset:     c.lateralOffset(ellipse),
+		heading:    headingError(ellipse),
+		confidence: float64(ellipse.GetConfidence()),
+		ts:         ts,
+	}
+	zap.S().Debugf("road offset: %v, heading: %v", estimate.offset, estimate.heading)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.road = &estimate
+}
+
+// Steering returns lane-keeping steering at ts, false if no usable road is available
+func (c *RoadCorrector) Steering(ts time.Time) (float64, bool) {
+	road, ok := c.estimate(ts)
+	if !ok {
+		return 0., false
+	}
+	return clamp(c.offsetGain*road.offset+c.headingGain*road.heading, -1., 1.), true
+}
+
+// estimate returns last road if it is usable at ts
+func (c *RoadCorrector) estimate(ts time.Time) (roadEstimate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.road == nil {
+		return roadEstimate{}, false
+	}
+	if c.ttl > 0 && ts.Sub(c.road.ts) > c.ttl {
+		return roadEstimate{}, false
+	}
+	if c.road.confidence < c.minRoadConfidence {
+		return roadEstimate{}, false
+	}
+	return *c.road, true
+}
+
+func (c *RoadCorrector) Process(s *Steering) {
+	if s.Source != SourceTF {
+		return
+	}
+	road, ok := c.Steering(s.Timestamp)
+	if !ok {
+		return
+	}
+
+	if s.Confidence < c.fallbackConfidence {
+		zap.S().Debugf("model confidence %v too low, use road steering: %v -> %v", s.Confidence, s.Value, road)
+		s.Value = road
+		return
+	}
+	value := clamp((1.-c.weight)*s.Value+c.weight*road, -1., 1.)
+	zap.S().Debugf("blend road steering %v: %v -> %v", road, s.Value, value)
+	s.Value = value
+}
+
+func (c *RoadCorrector) lateralOffset(ellipse *events.Ellipse) float64 {
+	middle := float64(c.imageWidth) / 2.
+	return clamp((float64(ellipse.GetCenter().GetX())-middle)/middle, -1., 1.)
+}
+
+/*
+headingError returns angle between major axis of ellipse and vertical axis, normalized in [-1,1].
+
+Ellipse angle is the rotation, in degrees, of width axis from horizontal axis, clockwise since image y axis points
+down: a positive heading means that far road is on the right.
+*/
+func headingError(ellipse *events.Ellipse) float64 {
+	angle := float64(ellipse.GetAngle())
+	if ellipse.GetWidth() > ellipse.GetHeight() {
+		// Major axis is width axis
+		angle -= 90.
+	}
+	angle = math.Mod(angle, 180.)
+	switch {
+	case angle > 90.:
+		angle -= 180.
+	case angle <= -90.:
+		angle += 180.
+	}
+	return angle / 90.
+}
@@ -0,0 +1,145 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+	"time"
+)
+
+func roadMessage(x int32, width, height int32, angle, confidence float32) *events.RoadMessage {
+	return &events.RoadMessage{
+		Ellipse: &events.Ellipse{
+			Center:     &events.Point{X: x, Y: 80},
+			Width:      width,
+			Height:     height,
+			Angle:      angle,
+			Confidence: confidence,
+		},
+	}
+}
+
+func Test_headingError(t *testing.T) {
+	tests := []struct {
+		name    string
+		ellipse *events.Ellipse
+		want    float64
+	}{
+		{name: "straight vertical ellipse", ellipse: &events.Ellipse{Width: 20, Height: 100, Angle: 0.}, want: 0.},
+		{name: "straight vertical ellipse rotated half turn", ellipse: &events.Ellipse{Width: 20, Height: 100, Angle: 180.}, want: 0.},
+		{name: "road on right", ellipse: &events.Ellipse{Width: 20, Height: 100, Angle: 30.}, want: 1. / 3.},
+		{name: "road on left", ellipse: &events.Ellipse{Width: 20, Height: 100, Angle: 150.}, want: -1. / 3.},
+		{name: "straight horizontal major axis", ellipse: &events.Ellipse{Width: 100, Height: 20, Angle: 90.}, want: 0.},
+		{name: "road on left with width major axis", ellipse: &events.Ellipse{Width: 100, Height: 20, Angle: 60.}, want: -1. / 3.},
+		{name: "road on right with width major axis", ellipse: &events.Ellipse{Width: 100, Height: 20, Angle: 135.}, want: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headingError(tt.ellipse); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("headingError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoadCorrector_Steering(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		options []OptionRoadCorrector
+		road    *events.RoadMessage
+		at      time.Time
+		want    float64
+		wantOk  bool
+	}{
+		{name: "no road", at: now, want: 0., wantOk: false},
+		{name: "road without ellipse", road: &events.RoadMessage{}, at: now, want: 0., wantOk: false},
+		{name: "centred road", road: roadMessage(80, 20, 100, 0., 1.), at: now, want: 0., wantOk: true},
+		{name: "road centre on right", road: roadMessage(120, 20, 100, 0., 1.), at: now, want: 0.5, wantOk: true},
+		{name: "road centre on left with heading on left", road: roadMessage(40, 20, 100, 162., 1.), at: now, want: -0.7, wantOk: true},
+		{
+			name:    "gains",
+			options: []OptionRoadCorrector{WithRoadGains(0.5, 2.)},
+			road:    roadMessage(120, 20, 100, 9., 1.), at: now, want: 0.45, wantOk: true,
+		},
+		{
+			name:    "image width",
+			options: []OptionRoadCorrector{WithRoadImageWidth(320)},
+			road:    roadMessage(240, 20, 100, 0., 1.), at: now, want: 0.5, wantOk: true,
+		},
+		{name: "saturation", road: roadMessage(160, 20, 100, 45., 1.), at: now, want: 1., wantOk: true},
+		{name: "stale road", road: roadMessage(120, 20, 100, 0., 1.), at: now.Add(time.Second), want: 0., wantOk: false},
+		{
+			name:    "low confidence road",
+			options: []OptionRoadCorrector{WithRoadMinConfidence(0.5)},
+			road:    roadMessage(120, 20, 100, 0., 0.4), at: now, want: 0., wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRoadCorrector(tt.options...)
+			if tt.road != nil {
+				c.OnRoad(tt.road, now)
+			}
+			got, ok := c.Steering(tt.at)
+			if ok != tt.wantOk {
+				t.Errorf("Steering() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("Steering() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoadCorrector_Process(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		options  []OptionRoadCorrector
+		steering Steering
+		want     float64
+	}{
+		{
+			name:     "blend road",
+			options:  []OptionRoadCorrector{WithRoadWeight(0.5)},
+			steering: Steering{Value: 0.1, Confidence: 1., Source: SourceTF, Timestamp: now},
+			want:     0.3,
+		},
+		{
+			name:     "rc steering not corrected",
+			options:  []OptionRoadCorrector{WithRoadWeight(0.5)},
+			steering: Steering{Value: 0.1, Confidence: 1., Source: SourceRC, Timestamp: now},
+			want:     0.1,
+		},
+		{
+			name:     "confident model",
+			options:  []OptionRoadCorrector{WithRoadWeight(0.5), WithRoadFallback(0.5)},
+			steering: Steering{Value: 0.1, Confidence: 0.6, Source: SourceTF, Timestamp: now},
+			want:     0.3,
+		},
+		{
+			name:     "fallback on low model confidence",
+			options:  []OptionRoadCorrector{WithRoadWeight(0.5), WithRoadFallback(0.5)},
+			steering: Steering{Value: -0.8, Confidence: 0.2, Source: SourceTF, Timestamp: now},
+			want:     0.5,
+		},
+		{
+			name:     "stale road",
+			options:  []OptionRoadCorrector{WithRoadWeight(0.5), WithRoadFallback(0.5)},
+			steering: Steering{Value: -0.8, Confidence: 0.2, Source: SourceTF, Timestamp: now.Add(time.Second)},
+			want:     -0.8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRoadCorrector(tt.options...)
+			c.OnRoad(roadMessage(120, 20, 100, 0., 1.), now)
+			s := tt.steering
+			c.Process(&s)
+			if math.Abs(s.Value-tt.want) > 1e-6 {
+				t.Errorf("Process() = %v, want %v", s.Value, tt.want)
+			}
+		})
+	}
+}
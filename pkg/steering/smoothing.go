@@ -0,0 +1,123 @@
+package steering
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// SteeringFilter smooths steering values over time
+type SteeringFilter interface {
+	Apply(steering float64, ts time.Time) float64
+}
+
+func NewSlewRateLimiter(maxRate float64) *SlewRateLimiter {
+	return &SlewRateLimiter{maxRate: maxRate}
+}
+
+// SlewRateLimiter limits steering variation to maxRate units per second
+type SlewRateLimiter struct {
+	mu       sync.Mutex
+	maxRate  float64
+	last     float64
+	lastTime time.Time
+}
+
+func (l *SlewRateLimiter) Apply(steering float64, ts time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.lastTime.IsZero() {
+		l.last, l.lastTime = steering, ts
+		return steering
+	}
+	dt := ts.Sub(l.lastTime).Seconds()
+	if dt < 0 {
+		// Message out of order, keep last value
+		return l.last
+	}
+	maxDelta := l.maxRate * dt
+	result := l.last + clamp(steering-l.last, -maxDelta, maxDelta)
+	l.last, l.lastTime = result, ts
+	return result
+}
+
+func NewEMAFilter(alpha float64) *EMAFilter {
+	return &EMAFilter{alpha: alpha}
+}
+
+// EMAFilter applies an exponential moving average, alpha is the weight of the new value
+type EMAFilter struct {
+	mu          sync.Mutex
+	alpha       float64
+	last        float64
+	initialized bool
+}
+
+func (f *EMAFilter) Apply(steering float64, _ time.Time) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.initialized {
+		f.last, f.initialized = steering, true
+		return steering
+	}
+	f.last = f.alpha*steering + (1-f.alpha)*f.last
+	return f.last
+}
+
+func NewOneEuroFilter(minCutoff, beta, dCutoff float64) *OneEuroFilter {
+	return &OneEuroFilter{minCutoff: minCutoff, beta: beta, dCutoff: dCutoff}
+}
+
+/*
+OneEuroFilter is an adaptive low-pass filter: cutoff frequency increases with speed of variation so that slow moves
+are smoothed while fast moves keep a low latency.
+
+  - minCutoff: minimal cutoff frequency in Hz, decrease to reduce jitter
+  - beta: speed coefficient, increase to reduce lag
+  - dCutoff: cutoff frequency in Hz used to smooth derivative
+
+See https://gery.casiez.net/1euro/
+*/
+type OneEuroFilter struct {
+	mu        sync.Mutex
+	minCutoff float64
+	beta      float64
+	dCutoff   float64
+
+	last      float64
+	lastDeriv float64
+	lastTime  time.Time
+}
+
+func (f *OneEuroFilter) Apply(steering float64, ts time.Time) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lastTime.IsZero() {
+		f.last, f.lastDeriv, f.lastTime = steering, 0., ts
+		return steering
+	}
+	dt := ts.Sub(f.lastTime).Seconds()
+	if dt <= 0 {
+		return f.last
+	}
+
+	deriv := (steering - f.last) / dt
+	f.lastDeriv = lowPass(f.lastDeriv, deriv, smoothingFactor(dt, f.dCutoff))
+
+	cutoff := f.minCutoff + f.beta*math.Abs(f.lastDeriv)
+	f.last = lowPass(f.last, steering, smoothingFactor(dt, cutoff))
+	f.lastTime = ts
+	return f.last
+}
+
+func smoothingFactor(dt, cutoff float64) float64 {
+	r := 2 * math.Pi * cutoff * dt
+	return r / (r + 1)
+}
+
+func lowPass(previous, value, alpha float64) float64 {
+	return alpha*value + (1-alpha)*previous
+}
@@ -0,0 +1,113 @@
+package steering
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type timedSteering struct {
+	offset   time.Duration
+	steering float64
+	want     float64
+}
+
+func runSteeringFilter(t *testing.T, f SteeringFilter, sequence []timedSteering) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, s := range sequence {
+		if got := f.Apply(s.steering, start.Add(s.offset)); math.Abs(got-s.want) > 1e-4 {
+			t.Errorf("step %d: Apply(%v) = %v, want %v", i, s.steering, got, s.want)
+		}
+	}
+}
+
+func TestSlewRateLimiter_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxRate  float64
+		sequence []timedSteering
+	}{
+		{
+			name:    "slow variations are kept",
+			maxRate: 2.,
+			sequence: []timedSteering{
+				{offset: 0, steering: 0., want: 0.},
+				{offset: 100 * time.Millisecond, steering: 0.1, want: 0.1},
+				{offset: 200 * time.Millisecond, steering: -0.1, want: -0.1},
+			},
+		},
+		{
+			name:    "full jump is limited",
+			maxRate: 2.,
+			sequence: []timedSteering{
+				{offset: 0, steering: -1., want: -1.},
+				{offset: 100 * time.Millisecond, steering: 1., want: -0.8},
+				{offset: 200 * time.Millisecond, steering: 1., want: -0.6},
+				{offset: 1200 * time.Millisecond, steering: 1., want: 1.},
+			},
+		},
+		{
+			name:    "out of order message",
+			maxRate: 2.,
+			sequence: []timedSteering{
+				{offset: 100 * time.Millisecond, steering: 0.5, want: 0.5},
+				{offset: 0, steering: 1., want: 0.5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteeringFilter(t, NewSlewRateLimiter(tt.maxRate), tt.sequence)
+		})
+	}
+}
+
+func TestEMAFilter_Apply(t *testing.T) {
+	runSteeringFilter(t, NewEMAFilter(0.5), []timedSteering{
+		{offset: 0, steering: 0., want: 0.},
+		{offset: 100 * time.Millisecond, steering: 1., want: 0.5},
+		{offset: 200 * time.Millisecond, steering: 1., want: 0.75},
+		{offset: 300 * time.Millisecond, steering: -1., want: -0.125},
+	})
+}
+
+func TestOneEuroFilter_Apply(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("jitter is smoothed", func(t *testing.T) {
+		f := NewOneEuroFilter(1., 0., 1.)
+		var got float64
+		for i := 0; i < 20; i++ {
+			jitter := 0.05
+			if i%2 == 1 {
+				jitter = -0.05
+			}
+			got = f.Apply(0.3+jitter, start.Add(time.Duration(i)*50*time.Millisecond))
+		}
+		if math.Abs(got-0.3) > 0.03 {
+			t.Errorf("Apply() = %v, want about 0.3", got)
+		}
+	})
+
+	t.Run("beta reduces lag on fast moves", func(t *testing.T) {
+		slow := NewOneEuroFilter(1., 0., 1.)
+		fast := NewOneEuroFilter(1., 10., 1.)
+		var gotSlow, gotFast float64
+		for i := 0; i < 5; i++ {
+			ts := start.Add(time.Duration(i) * 50 * time.Millisecond)
+			steering := float64(i) * 0.2
+			gotSlow = slow.Apply(steering, ts)
+			gotFast = fast.Apply(steering, ts)
+		}
+		if gotFast <= gotSlow {
+			t.Errorf("Apply() with beta = %v, must be nearer of 0.8 than without beta: %v", gotFast, gotSlow)
+		}
+	})
+
+	t.Run("first value", func(t *testing.T) {
+		runSteeringFilter(t, NewOneEuroFilter(1., 0., 1.), []timedSteering{
+			{offset: 0, steering: 0.7, want: 0.7},
+			{offset: 0, steering: 0.2, want: 0.7},
+		})
+	})
+}
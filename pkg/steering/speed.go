@@ -0,0 +1,139 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+// Speed is the last speed information received, Throttle is 0 and Zone is UNKNOWN when not available
+type Speed struct {
+	Throttle float64
+	Zone     events.SpeedZone
+}
+
+// SpeedAwareCorrector is implemented by correctors that adapt correction to car speed
+type SpeedAwareCorrector interface {
+	AdjustWithSpeed(currentSteering float64, objects []*events.Object, speed Speed) float64
+}
+
+// SpeedAwareDiagnosticCorrector is a SpeedAwareCorrector able to explain its correction
+type SpeedAwareDiagnosticCorrector interface {
+	DiagnoseWithSpeed(currentSteering float64, objects []*events.Object, speed Speed) (float64, Diagnosis)
+}
+
+type OptionSpeedCorrector func(c *SpeedCorrector)
+
+// WithZoneCorrector defines corrector to use, in place of default corrector, when car drives in zone
+func WithZoneCorrector(zone events.SpeedZone, corrector Corrector) OptionSpeedCorrector {
+	return func(c *SpeedCorrector) {
+		c.zoneCorrectors[zone] = corrector
+	}
+}
+
+// WithThrottleGain defines factor to apply on correction when car is stopped and at full throttle
+func WithThrottleGain(atStop, atFullThrottle float64) OptionSpeedCorrector {
+	return func(c *SpeedCorrector) {
+		c.gainAtStop = atStop
+		c.gainAtFullThrottle = atFullThrottle
+	}
+}
+
+func NewSpeedCorrector(defaultCorrector Corrector, options ...OptionSpeedCorrector) *SpeedCorrector {
+	c := &SpeedCorrector{
+		defaultCorrector:   defaultCorrector,
+		zoneCorrectors:     make(map[events.SpeedZone]Corrector),
+		gainAtStop:         1.,
+		gainAtFullThrottle: 1.,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+/*
+SpeedCorrector adapts avoidance to car speed.
+
+Corrector is selected from speed zone, with fallback to default corrector for zones without dedicated corrector.
+Correction is then scaled by a gain that increases linearly with absolute throttle, from gainAtStop to
+gainAtFullThrottle.
+*/
+type SpeedCorrector struct {
+	defaultCorrector   Corrector
+	zoneCorrectors     map[events.SpeedZone]Corrector
+	gainAtStop         float64
+	gainAtFullThrottle float64
+}
+
+// AdjustFromObjectPosition uses default corrector when speed is unknown
+func (c *SpeedCorrector) AdjustFromObjectPosition(currentSteering float64, objects []*events.Object) float64 {
+	return c.AdjustWithSpeed(currentSteering, objects, Speed{})
+}
+
+func (c *SpeedCorrector) AdjustWithSpeed(currentSteering float64, objects []*events.Object, speed Speed) float64 {
+	value, _ := c.DiagnoseWithSpeed(currentSteering, objects, speed)
+	return value
+}
+
+// DiagnoseWithSpeed adjusts steering like AdjustWithSpeed and explains correction, if zone corrector is able to
+func (c *SpeedCorrector) DiagnoseWithSpeed(currentSteering float64, objects []*events.Object, speed Speed) (float64, Diagnosis) {
+	var diagnosis Diagnosis
+	if len(objects) == 0 {
+		return currentSteering, diagnosis
+	}
+
+	corrector := c.correctorOf(speed.Zone)
+	var value float64
+	if dc, ok := corrector.(DiagnosticCorrector); ok {
+		value, diagnosis = dc.Diagnose(currentSteering, objects)
+	} else {
+		value = corrector.AdjustFromObjectPosition(currentSteering, objects)
+	}
+	delta := value - currentSteering
+	if delta == 0. {
+		return currentSteering, diagnosis
+	}
+
+	gain := c.gain(speed.Throttle)
+	zap.S().Debugf("speed %v, scale correction by %v", speed, gain)
+	result := currentSteering + delta*gain
+	diagnosis.Clamped = result < -1. || result > 1.
+	return clamp(result, -1., 1.), diagnosis
+}
+
+// OnObjects forwards objects to each corrector that needs all objects messages
+func (c *SpeedCorrector) OnObjects(objects []*events.Object, ts time.Time) {
+	notified := make(map[Corrector]bool, len(c.zoneCorrectors)+1)
+	for _, corrector := range append([]Corrector{c.defaultCorrector}, c.correctorsOfZones()...) {
+		observer, ok := corrector.(ObjectsObserver)
+		if !ok || notified[corrector] {
+			continue
+		}
+		notified[corrector] = true
+		observer.OnObjects(objects, ts)
+	}
+}
+
+func (c *SpeedCorrector) correctorOf(zone events.SpeedZone) Corrector {
+	if corrector, ok := c.zoneCorrectors[zone]; ok {
+		return corrector
+	}
+	return c.defaultCorrector
+}
+
+func (c *SpeedCorrector) correctorsOfZones() []Corrector {
+	correctors := make([]Corrector, 0, len(c.zoneCorrectors))
+	for _, zone := range []events.SpeedZone{events.SpeedZone_UNKNOWN, events.SpeedZone_SLOW, events.SpeedZone_NORMAL, events.SpeedZone_FAST} {
+		if corrector, ok := c.zoneCorrectors[zone]; ok {
+			correctors = append(correctors, corrector)
+		}
+	}
+	return correctors
+}
+
+func (c *SpeedCorrector) gain(throttle float64) float64 {
+	t := math.Min(math.Abs(throttle), 1.)
+	return c.gainAtStop + (c.gainAtFullThrottle-c.gainAtStop)*t
+}
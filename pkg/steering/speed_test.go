@@ -0,0 +1,124 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpeedCorrector_AdjustWithSpeed(t *testing.T) {
+	slow := &StaticCorrector{delta: 0.2}
+	fast := &StaticCorrector{delta: 0.6}
+	objects := []*events.Object{&objectOnMiddleNear}
+
+	tests := []struct {
+		name     string
+		options  []OptionSpeedCorrector
+		steering float64
+		objects  []*events.Object
+		speed    Speed
+		want     float64
+	}{
+		{
+			name:     "default corrector on unknown zone",
+			options:  []OptionSpeedCorrector{WithZoneCorrector(events.SpeedZone_FAST, fast)},
+			steering: 0., objects: objects, speed: Speed{Zone: events.SpeedZone_UNKNOWN},
+			want: 0.2,
+		},
+		{
+			name:     "zone corrector",
+			options:  []OptionSpeedCorrector{WithZoneCorrector(events.SpeedZone_FAST, fast)},
+			steering: 0., objects: objects, speed: Speed{Zone: events.SpeedZone_FAST},
+			want: 0.6,
+		},
+		{
+			name:     "default corrector on zone without corrector",
+			options:  []OptionSpeedCorrector{WithZoneCorrector(events.SpeedZone_FAST, fast)},
+			steering: 0., objects: objects, speed: Speed{Zone: events.SpeedZone_NORMAL},
+			want: 0.2,
+		},
+		{
+			name:     "gain at stop",
+			options:  []OptionSpeedCorrector{WithThrottleGain(0.5, 2.)},
+			steering: 0., objects: objects, speed: Speed{Throttle: 0.},
+			want: 0.1,
+		},
+		{
+			name:     "gain at half throttle",
+			options:  []OptionSpeedCorrector{WithThrottleGain(0.5, 2.)},
+			steering: 0., objects: objects, speed: Speed{Throttle: 0.5},
+			want: 0.25,
+		},
+		{
+			name:     "gain on reverse",
+			options:  []OptionSpeedCorrector{WithThrottleGain(0.5, 2.)},
+			steering: 0., objects: objects, speed: Speed{Throttle: -1.},
+			want: 0.4,
+		},
+		{
+			name:     "gain limited to full throttle",
+			options:  []OptionSpeedCorrector{WithThrottleGain(0.5, 2.)},
+			steering: 0., objects: objects, speed: Speed{Throttle: 1.5},
+			want: 0.4,
+		},
+		{
+			name:     "gain with zone corrector and saturation",
+			options:  []OptionSpeedCorrector{WithZoneCorrector(events.SpeedZone_FAST, fast), WithThrottleGain(1., 2.)},
+			steering: 0.1, objects: objects, speed: Speed{Zone: events.SpeedZone_FAST, Throttle: 1.},
+			want: 1.,
+		},
+		{
+			name:     "no objects",
+			options:  []OptionSpeedCorrector{WithThrottleGain(0.5, 2.)},
+			steering: 0.3, objects: []*events.Object{}, speed: Speed{Throttle: 1.},
+			want: 0.3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSpeedCorrector(slow, tt.options...)
+			if got := c.AdjustWithSpeed(tt.steering, tt.objects, tt.speed); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("AdjustWithSpeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeedCorrector_AdjustFromObjectPosition(t *testing.T) {
+	c := NewSpeedCorrector(&StaticCorrector{delta: 0.2},
+		WithZoneCorrector(events.SpeedZone_UNKNOWN, &StaticCorrector{delta: 0.4}),
+		WithThrottleGain(0.5, 1.),
+	)
+	if got := c.AdjustFromObjectPosition(0., []*events.Object{&objectOnMiddleNear}); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, 0.2)
+	}
+}
+
+func TestSpeedCorrector_OnObjects(t *testing.T) {
+	defaultCorrector := &recordCorrector{}
+	fast := &recordCorrector{}
+	c := NewSpeedCorrector(defaultCorrector,
+		WithZoneCorrector(events.SpeedZone_SLOW, defaultCorrector),
+		WithZoneCorrector(events.SpeedZone_FAST, fast),
+		WithZoneCorrector(events.SpeedZone_NORMAL, &StaticCorrector{}),
+	)
+	c.OnObjects([]*events.Object{}, time.Now())
+	if defaultCorrector.observed != 1 {
+		t.Errorf("OnObjects() forwarded %v times to default corrector, want 1", defaultCorrector.observed)
+	}
+	if fast.observed != 1 {
+		t.Errorf("OnObjects() forwarded %v times to zone corrector, want 1", fast.observed)
+	}
+}
+
+func TestCorrectorProcessor_SpeedAware(t *testing.T) {
+	p := NewCorrectorProcessor(NewSpeedCorrector(&StaticCorrector{delta: 0.2}, WithThrottleGain(0., 1.)), false)
+	s := Steering{Value: 0., Source: SourceTF, Speed: Speed{Throttle: 0.5}, objects: func() []*events.Object {
+		return []*events.Object{&objectOnMiddleNear}
+	}}
+	p.Process(&s)
+	if math.Abs(s.Value-0.1) > 1e-9 {
+		t.Errorf("Process() = %v, want %v", s.Value, 0.1)
+	}
+}
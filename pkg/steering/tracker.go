@@ -0,0 +1,299 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"time"
+)
+
+type OptionTracker func(t *Tracker)
+
+// WithIoUThreshold defines minimal intersection over union to associate a detection to a track
+func WithIoUThreshold(threshold float64) OptionTracker {
+	return func(t *Tracker) {
+		t.iouThreshold = threshold
+	}
+}
+
+// WithMinHits defines count of associated detections before to confirm a track
+func WithMinHits(hits int) OptionTracker {
+	return func(t *Tracker) {
+		t.minHits = hits
+	}
+}
+
+// WithMaxMisses defines count of successive frames without detection before to drop a track
+func WithMaxMisses(misses int) OptionTracker {
+	return func(t *Tracker) {
+		t.maxMisses = misses
+	}
+}
+
+// WithSmoothing defines weight, between 0 and 1, of a new detection in the smoothed box and velocities
+func WithSmoothing(alpha float64) OptionTracker {
+	return func(t *Tracker) {
+		t.alpha = alpha
+	}
+}
+
+func NewTracker(options ...OptionTracker) *Tracker {
+	t := &Tracker{
+		iouThreshold: 0.3,
+		minHits:      3,
+		maxMisses:    2,
+		alpha:        0.5,
+		nextId:       1,
+	}
+	for _, o := range options {
+		o(t)
+	}
+	return t
+}
+
+/*
+Tracker associates objects across successive frames to smooth their boxes and estimate their velocities.
+
+Detections are associated to tracks with a greedy intersection over union matching. A track is confirmed after minHits
+detections and is kept up to maxMisses frames without detection.
+*/
+type Tracker struct {
+	mu           sync.Mutex
+	iouThreshold float64
+	minHits      int
+	maxMisses    int
+	alpha        float64
+
+	tracks []*Track
+	nextId int
+}
+
+// Track is an object followed across frames
+type Track struct {
+	Id int
+	// Age is the count of frames since track creation
+	Age int
+	// Hits is the count of detections associated to track
+	Hits int
+	// Misses is the count of successive frames without detection
+	Misses int
+	// Box is the smoothed object box
+	Box *events.Object
+	// LateralVelocity is the horizontal velocity of box centre, in image width percent per second, positive to right
+	LateralVelocity float64
+	// ApproachVelocity is the velocity of box bottom, in image height percent per second, positive when object comes
+	// closer
+	ApproachVelocity float64
+	// HeightVelocity is the growth rate of box height, in image height percent per second, positive when box grows
+	HeightVelocity float64
+	// DistanceVelocity is the variation of metric distance, in mm per second, negative when object comes closer. Only
+	// available when detections have a metric distance
+	DistanceVelocity float64
+
+	lastUpdate time.Time
+}
+
+func (t *Track) confirmed(minHits int) bool {
+	return t.Hits >= minHits
+}
+
+// predict returns box moved according velocities at time ts
+func (t *Track) predict(ts time.Time) *events.Object {
+	box := copyObject(t.Box)
+	dt := ts.Sub(t.lastUpdate).Seconds()
+	if dt <= 0 {
+		return box
+	}
+	dx := float32(t.LateralVelocity * dt)
+	dy := float32(t.ApproachVelocity * dt)
+	box.Left += dx
+	box.Right += dx
+	box.Top += dy
+	box.Bottom += dy
+	return box
+}
+
+/*
+Update associates objects detected at time ts to tracks and returns smoothed objects of confirmed tracks
+*/
+func (t *Tracker) Update(objects []*events.Object, ts time.Time) []*events.Object {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	matches, unmatchedObjects := t.associate(objects, ts)
+	for _, tr := range t.tracks {
+		tr.Age += 1
+		obj, ok := matches[tr]
+		if !ok {
+			tr.Misses += 1
+			continue
+		}
+		t.updateTrack(tr, obj, ts)
+	}
+
+	tracks := make([]*Track, 0, len(t.tracks)+len(unmatchedObjects))
+	for _, tr := range t.tracks {
+		if tr.Misses > t.maxMisses {
+			zap.S().Debugf("drop track %v after %v misses", tr.Id, tr.Misses)
+			continue
+		}
+		tracks = append(tracks, tr)
+	}
+	for _, o := range unmatchedObjects {
+		tracks = append(tracks, &Track{Id: t.nextId, Age: 1, Hits: 1, Box: copyObject(o), lastUpdate: ts})
+		t.nextId += 1
+	}
+	t.tracks = tracks
+
+	return t.confirmedObjects()
+}
+
+// Tracks returns a copy of confirmed tracks
+func (t *Tracker) Tracks() []Track {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	res := make([]Track, 0, len(t.tracks))
+	for _, tr := range t.tracks {
+		if tr.confirmed(t.minHits) {
+			cpy := *tr
+			cpy.Box = copyObject(tr.Box)
+			res = append(res, cpy)
+		}
+	}
+	return res
+}
+
+func (t *Tracker) confirmedObjects() []*events.Object {
+	res := make([]*events.Object, 0, len(t.tracks))
+	for _, tr := range t.tracks {
+		if tr.confirmed(t.minHits) {
+			res = append(res, copyObject(tr.Box))
+		}
+	}
+	return res
+}
+
+/*
+associate matches objects to tracks, greedily by decreasing intersection over union. Objects are compared to boxes
+predicted at time ts from tracks velocities.
+*/
+func (t *Tracker) associate(objects []*events.Object, ts time.Time) (map[*Track]*events.Object, []*events.Object) {
+	type pair struct {
+		track  *Track
+		object int
+		iou    float64
+	}
+	pairs := make([]pair, 0, len(t.tracks)*len(objects))
+	for _, tr := range t.tracks {
+		predicted := tr.predict(ts)
+		for i, o := range objects {
+			if o.Type != tr.Box.Type {
+				continue
+			}
+			if iou := intersectionOverUnion(predicted, o); iou >= t.iouThreshold {
+				pairs = append(pairs, pair{track: tr, object: i, iou: iou})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].iou > pairs[j].iou
+	})
+
+	matches := make(map[*Track]*events.Object, len(t.tracks))
+	matchedObjects := make(map[int]bool, len(objects))
+	for _, p := range pairs {
+		if _, ok := matches[p.track]; ok || matchedObjects[p.object] {
+			continue
+		}
+		matches[p.track] = objects[p.object]
+		matchedObjects[p.object] = true
+	}
+
+	unmatched := make([]*events.Object, 0, len(objects)-len(matchedObjects))
+	for i, o := range objects {
+		if !matchedObjects[i] {
+			unmatched = append(unmatched, o)
+		}
+	}
+	return matches, unmatched
+}
+
+func (t *Tracker) updateTrack(tr *Track, obj *events.Object, ts time.Time) {
+	previous := tr.Box
+	smoothed := &events.Object{
+		Type:       obj.Type,
+		Left:       float32(t.smooth(float64(previous.Left), float64(obj.Left))),
+		Top:        float32(t.smooth(float64(previous.Top), float64(obj.Top))),
+		Right:      float32(t.smooth(float64(previous.Right), float64(obj.Right))),
+		Bottom:     float32(t.smooth(float64(previous.Bottom), float64(obj.Bottom))),
+		Confidence: float32(t.smooth(float64(previous.Confidence), float64(obj.Confidence))),
+	}
+	if obj.DistanceInMm > 0 && previous.DistanceInMm > 0 {
+		smoothed.DistanceInMm = int64(t.smooth(float64(previous.DistanceInMm), float64(obj.DistanceInMm)))
+	} else {
+		smoothed.DistanceInMm = obj.DistanceInMm
+	}
+
+	if dt := ts.Sub(tr.lastUpdate).Seconds(); dt > 0 {
+		lateral := (centreX(smoothed) - centreX(previous)) / dt
+		approach := float64(smoothed.Bottom-previous.Bottom) / dt
+		height := (boxHeight(smoothed) - boxHeight(previous)) / dt
+		var distance float64
+		if smoothed.DistanceInMm > 0 && previous.DistanceInMm > 0 {
+			distance = float64(smoothed.DistanceInMm-previous.DistanceInMm) / dt
+		}
+		if tr.Hits == 1 {
+			// First estimation
+			tr.LateralVelocity, tr.ApproachVelocity, tr.HeightVelocity, tr.DistanceVelocity = lateral, approach, height, distance
+		} else {
+			tr.LateralVelocity = t.smooth(tr.LateralVelocity, lateral)
+			tr.ApproachVelocity = t.smooth(tr.ApproachVelocity, approach)
+			tr.HeightVelocity = t.smooth(tr.HeightVelocity, height)
+			tr.DistanceVelocity = t.smooth(tr.DistanceVelocity, distance)
+		}
+	}
+
+	tr.Box = smoothed
+	tr.Hits += 1
+	tr.Misses = 0
+	tr.lastUpdate = ts
+}
+
+func (t *Tracker) smooth(previous, value float64) float64 {
+	return t.alpha*value + (1-t.alpha)*previous
+}
+
+func centreX(o *events.Object) float64 {
+	return (float64(o.Left) + float64(o.Right)) / 2.
+}
+
+func boxHeight(o *events.Object) float64 {
+	return float64(o.Bottom) - float64(o.Top)
+}
+
+func intersectionOverUnion(a, b *events.Object) float64 {
+	interWidth := min(a.Right, b.Right) - max(a.Left, b.Left)
+	interHeight := min(a.Bottom, b.Bottom) - max(a.Top, b.Top)
+	if interWidth <= 0 || interHeight <= 0 {
+		return 0.
+	}
+	inter := float64(interWidth * interHeight)
+	union := float64((a.Right-a.Left)*(a.Bottom-a.Top)+(b.Right-b.Left)*(b.Bottom-b.Top)) - inter
+	if union <= 0 {
+		return 0.
+	}
+	return inter / union
+}
+
+func copyObject(o *events.Object) *events.Object {
+	return &events.Object{
+		Type:         o.Type,
+		Left:         o.Left,
+		Top:          o.Top,
+		Right:        o.Right,
+		Bottom:       o.Bottom,
+		Confidence:   o.Confidence,
+		DistanceInMm: o.DistanceInMm,
+	}
+}
@@ -0,0 +1,121 @@
+package steering
+
+import (
+	"encoding/json"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+type recordedDetections struct {
+	TimestampMs int64            `json:"timestamp_ms"`
+	Objects     []*events.Object `json:"objects"`
+}
+
+func loadRecordedDetections(t *testing.T, fileName string) []recordedDetections {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unable to read %v: %v", fileName, err)
+	}
+	var records []recordedDetections
+	if err := json.Unmarshal(content, &records); err != nil {
+		t.Fatalf("unable to unmarshal %v: %v", fileName, err)
+	}
+	return records
+}
+
+func TestTracker_Update(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := loadRecordedDetections(t, "test_data/tracks-01.json")
+
+	tracker := NewTracker(WithMinHits(3), WithMaxMisses(2))
+
+	// Cone is confirmed at the third frame and kept when detection flickers, spurious detection is never confirmed
+	wantObjects := []int{0, 0, 1, 1, 1, 1}
+	wantTracks := []int{1, 2, 2, 2, 1, 1}
+	for i, r := range records {
+		got := tracker.Update(r.Objects, start.Add(time.Duration(r.TimestampMs)*time.Millisecond))
+		if len(got) != wantObjects[i] {
+			t.Errorf("frame %d: Update() returns %d objects, want %d", i, len(got), wantObjects[i])
+		}
+		if len(tracker.tracks) != wantTracks[i] {
+			t.Errorf("frame %d: %d tracks, want %d", i, len(tracker.tracks), wantTracks[i])
+		}
+	}
+
+	tracks := tracker.Tracks()
+	if len(tracks) != 1 {
+		t.Fatalf("Tracks() = %v, want 1 track", tracks)
+	}
+	tr := tracks[0]
+	if tr.Id != 1 {
+		t.Errorf("bad track id: %v, want %v", tr.Id, 1)
+	}
+	if tr.Age != 6 || tr.Hits != 5 || tr.Misses != 0 {
+		t.Errorf("bad track age/hits/misses: %v/%v/%v, want 6/5/0", tr.Age, tr.Hits, tr.Misses)
+	}
+	if tr.ApproachVelocity <= 0. {
+		t.Errorf("bad approach velocity: %v, object comes closer", tr.ApproachVelocity)
+	}
+	if tr.LateralVelocity <= 0. {
+		t.Errorf("bad lateral velocity: %v, object moves to right", tr.LateralVelocity)
+	}
+	// Smoothed box is between previous and last detection
+	if tr.Box.Bottom <= 0.64 || tr.Box.Bottom > 0.70 {
+		t.Errorf("bad smoothed bottom: %v", tr.Box.Bottom)
+	}
+}
+
+func TestTracker_Update_DistanceVelocity(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(WithMinHits(1), WithSmoothing(1.))
+	for i, d := range []int64{2000, 1800, 1600} {
+		tracker.Update(
+			[]*events.Object{{Left: 0.4, Top: 0.5, Right: 0.6, Bottom: 0.7, Confidence: 0.9, DistanceInMm: d}},
+			start.Add(time.Duration(i)*100*time.Millisecond),
+		)
+	}
+	tracks := tracker.Tracks()
+	if len(tracks) != 1 {
+		t.Fatalf("Tracks() = %v, want 1 track", tracks)
+	}
+	if math.Abs(tracks[0].DistanceVelocity-(-2000.)) > 1e-6 {
+		t.Errorf("bad distance velocity: %v, want %v", tracks[0].DistanceVelocity, -2000.)
+	}
+}
+
+func Test_intersectionOverUnion(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *events.Object
+		want float64
+	}{
+		{
+			name: "same box",
+			a:    &events.Object{Left: 0.1, Top: 0.1, Right: 0.3, Bottom: 0.3},
+			b:    &events.Object{Left: 0.1, Top: 0.1, Right: 0.3, Bottom: 0.3},
+			want: 1.,
+		},
+		{
+			name: "half overlap",
+			a:    &events.Object{Left: 0.1, Top: 0.1, Right: 0.3, Bottom: 0.3},
+			b:    &events.Object{Left: 0.2, Top: 0.1, Right: 0.4, Bottom: 0.3},
+			want: 1. / 3.,
+		},
+		{
+			name: "disjoint boxes",
+			a:    &events.Object{Left: 0.1, Top: 0.1, Right: 0.3, Bottom: 0.3},
+			b:    &events.Object{Left: 0.5, Top: 0.5, Right: 0.6, Bottom: 0.6},
+			want: 0.,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := intersectionOverUnion(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("intersectionOverUnion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package steering
+
+import (
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+func NewTransition(duration time.Duration) *Transition {
+	return &Transition{duration: duration}
+}
+
+/*
+Transition ramps steering from last published value to the value of new source after a drive mode change, to avoid
+sudden wheel moves. Steering of new source is reached linearly after duration.
+*/
+type Transition struct {
+	duration time.Duration
+
+	mu        sync.Mutex
+	last      float64
+	published bool
+	from      float64
+	startedAt time.Time
+	running   bool
+}
+
+// Start begins a ramp from last published value, only if a value has already been published
+func (t *Transition) Start(ts time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.published || t.duration <= 0 {
+		return
+	}
+	t.from, t.startedAt, t.running = t.last, ts, true
+	zap.S().Infof("start steering transition from %v over %v", t.from, t.duration)
+}
+
+// Apply returns steering ramped at ts and records it as last published value
+func (t *Transition) Apply(steering float64, ts time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	value := steering
+	if t.running {
+		progress := float64(ts.Sub(t.startedAt)) / float64(t.duration)
+		if progress >= 1. {
+			zap.S().Infof("steering transition done")
+			t.running = false
+		} else {
+			value = t.from + (steering-t.from)*max(progress, 0.)
+			zap.S().Debugf("steering transition %.0f%%: %v -> %v", progress*100, steering, value)
+		}
+	}
+	t.last, t.published = value, true
+	return value
+}
+
+func (t *Transition) Process(s *Steering) {
+	s.Value = t.Apply(s.Value, s.ReceivedAt)
+}
@@ -0,0 +1,85 @@
+package steering
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTransition_Apply(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		offset   time.Duration
+		start    bool
+		steering float64
+		want     float64
+	}
+	tests := []struct {
+		name     string
+		duration time.Duration
+		steps    []step
+	}{
+		{
+			name:     "without drive mode change",
+			duration: time.Second,
+			steps: []step{
+				{offset: 0, steering: 0.5, want: 0.5},
+				{offset: 100 * time.Millisecond, steering: -0.5, want: -0.5},
+			},
+		},
+		{
+			name:     "ramp after drive mode change",
+			duration: time.Second,
+			steps: []step{
+				{offset: 0, steering: 0.8, want: 0.8},
+				{offset: 100 * time.Millisecond, start: true, steering: -0.8, want: 0.8},
+				{offset: 600 * time.Millisecond, steering: -0.8, want: 0.},
+				{offset: 850 * time.Millisecond, steering: 0., want: 0.2},
+				{offset: 1100 * time.Millisecond, steering: -0.8, want: -0.8},
+				{offset: 1200 * time.Millisecond, steering: 0.4, want: 0.4},
+			},
+		},
+		{
+			name:     "drive mode change before first steering",
+			duration: time.Second,
+			steps: []step{
+				{offset: 0, start: true, steering: 0.8, want: 0.8},
+			},
+		},
+		{
+			name:     "new drive mode change during ramp",
+			duration: time.Second,
+			steps: []step{
+				{offset: 0, steering: 1., want: 1.},
+				{offset: 0, start: true, steering: 0., want: 1.},
+				{offset: 500 * time.Millisecond, steering: 0., want: 0.5},
+				// Restart from current ramp value
+				{offset: 500 * time.Millisecond, start: true, steering: 0., want: 0.5},
+				{offset: 1000 * time.Millisecond, steering: -1., want: -0.25},
+			},
+		},
+		{
+			name:     "disabled",
+			duration: 0,
+			steps: []step{
+				{offset: 0, steering: 0.8, want: 0.8},
+				{offset: 100 * time.Millisecond, start: true, steering: -0.8, want: -0.8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTransition(tt.duration)
+			for i, st := range tt.steps {
+				ts := start.Add(st.offset)
+				if st.start {
+					tr.Start(ts)
+				}
+				if got := tr.Apply(st.steering, ts); math.Abs(got-st.want) > 1e-9 {
+					t.Errorf("step %d: Apply() = %v, want %v", i, got, st.want)
+				}
+			}
+		})
+	}
+}
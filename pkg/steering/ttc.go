@@ -0,0 +1,143 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"go.uber.org/zap"
+	"math"
+	"time"
+)
+
+// ObjectsObserver is implemented by correctors that need all objects messages, not only objects at steering time
+type ObjectsObserver interface {
+	OnObjects(objects []*events.Object, ts time.Time)
+}
+
+type OptionTTCCorrector func(c *TTCCorrector)
+
+// WithTTCHorizon defines time to collision below which avoidance is amplified
+func WithTTCHorizon(h time.Duration) OptionTTCCorrector {
+	return func(c *TTCCorrector) {
+		c.horizon = h
+	}
+}
+
+// WithTTCMaxGain defines factor to apply on grid correction for an imminent collision
+func WithTTCMaxGain(g float64) OptionTTCCorrector {
+	return func(c *TTCCorrector) {
+		c.maxGain = g
+	}
+}
+
+// WithTTCTracker defines tracker used to estimate objects approach speed
+func WithTTCTracker(t *Tracker) OptionTTCCorrector {
+	return func(c *TTCCorrector) {
+		c.tracker = t
+	}
+}
+
+func NewTTCCorrector(grid *GridCorrector, options ...OptionTTCCorrector) *TTCCorrector {
+	c := &TTCCorrector{
+		grid:    grid,
+		tracker: NewTracker(),
+		horizon: 2 * time.Second,
+		maxGain: 1.5,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+/*
+TTCCorrector scales GridCorrector avoidance by collision urgency.
+
+Time to collision of each object is estimated from its tracked metric distance variation if available, from the growth
+rate of its box height else. Urgency increases linearly from 0, when time to collision is greater than horizon, to 1,
+for an imminent collision, and grid correction is scaled by 1 + urgency * (maxGain - 1): urgency only amplifies
+avoidance, objects that don't come closer keep GridCorrector behaviour.
+
+Objects without enough history use GridCorrector behaviour. The strongest scale among objects is applied.
+*/
+type TTCCorrector struct {
+	grid    *GridCorrector
+	tracker *Tracker
+	horizon time.Duration
+	maxGain float64
+}
+
+func (c *TTCCorrector) OnObjects(objects []*events.Object, ts time.Time) {
+	c.tracker.Update(objects, ts)
+}
+
+func (c *TTCCorrector) AdjustFromObjectPosition(currentSteering float64, objects []*events.Object) float64 {
+	if len(objects) == 0 {
+		return currentSteering
+	}
+
+	delta := c.grid.AdjustFromObjectPosition(currentSteering, objects) - currentSteering
+	if delta == 0. {
+		return currentSteering
+	}
+
+	tracks := c.tracker.Tracks()
+	scale := 0.
+	for _, o := range objects {
+		s := 1.
+		if ttc, ok := c.timeToCollision(o, tracks); ok {
+			s = 1. + c.urgency(ttc)*(c.maxGain-1.)
+			zap.S().Debugf("time to collision: %v, scale correction by %v", ttc, s)
+		}
+		scale = math.Max(scale, s)
+	}
+
+	return clamp(currentSteering+delta*scale, -1., 1.)
+}
+
+// urgency returns 0 for a time to collision greater than horizon up to 1 for an imminent collision
+func (c *TTCCorrector) urgency(ttc time.Duration) float64 {
+	if ttc >= c.horizon {
+		return 0.
+	}
+	if ttc <= 0 {
+		return 1.
+	}
+	return 1. - float64(ttc)/float64(c.horizon)
+}
+
+/*
+timeToCollision searches track of object and estimates its time to collision. Returns false if object isn't tracked.
+Objects that don't come closer have an infinite time to collision.
+*/
+func (c *TTCCorrector) timeToCollision(o *events.Object, tracks []Track) (time.Duration, bool) {
+	var track *Track
+	bestIoU := c.tracker.iouThreshold
+	for i := range tracks {
+		if iou := intersectionOverUnion(tracks[i].Box, o); iou >= bestIoU {
+			bestIoU = iou
+			track = &tracks[i]
+		}
+	}
+	if track == nil || track.Hits < 2 {
+		// Velocities need at least 2 detections
+		return 0, false
+	}
+
+	var seconds float64
+	switch {
+	case track.Box.DistanceInMm > 0 && track.DistanceVelocity != 0.:
+		if track.DistanceVelocity > 0. {
+			return time.Duration(math.MaxInt64), true
+		}
+		seconds = float64(track.Box.DistanceInMm) / -track.DistanceVelocity
+	case track.HeightVelocity > 0.:
+		// Apparent size is inversely proportional to distance: ttc = h / (dh/dt)
+		seconds = boxHeight(track.Box) / track.HeightVelocity
+	default:
+		return time.Duration(math.MaxInt64), true
+	}
+	// Slow approach gives huge values that overflow Duration
+	if math.IsNaN(seconds) || seconds >= c.horizon.Seconds() {
+		return time.Duration(math.MaxInt64), true
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,102 @@
+package steering
+
+import (
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTTCCorrector_AdjustFromObjectPosition(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	objectOnLeftAt := func(top float32, distance int64) *events.Object {
+		return &events.Object{Left: 0.1, Top: top, Right: 0.3, Bottom: 0.9, Confidence: 0.9, DistanceInMm: distance}
+	}
+
+	tests := []struct {
+		name    string
+		history []*events.Object
+		object  *events.Object
+		want    float64
+	}{
+		{
+			name:   "without history, use grid correction",
+			object: objectOnLeftAt(0.8, 0),
+			want:   0.25,
+		},
+		{
+			name:    "only one detection, use grid correction",
+			history: []*events.Object{objectOnLeftAt(0.8, 0)},
+			object:  objectOnLeftAt(0.8, 0),
+			want:    0.25,
+		},
+		{
+			name:    "box grows, ttc 0.7s",
+			history: []*events.Object{objectOnLeftAt(0.8, 0), objectOnLeftAt(0.78, 0), objectOnLeftAt(0.76, 0)},
+			object:  objectOnLeftAt(0.76, 0),
+			want:    0.25 * (1. + (1.-0.7/2.)*0.5),
+		},
+		{
+			name:    "box shrinks, object doesn't come closer, use grid correction",
+			history: []*events.Object{objectOnLeftAt(0.76, 0), objectOnLeftAt(0.78, 0), objectOnLeftAt(0.8, 0)},
+			object:  objectOnLeftAt(0.8, 0),
+			want:    0.25,
+		},
+		{
+			name:    "distance decreases, ttc 0.8s",
+			history: []*events.Object{objectOnLeftAt(0.8, 1000), objectOnLeftAt(0.8, 900), objectOnLeftAt(0.8, 800)},
+			object:  objectOnLeftAt(0.8, 800),
+			want:    0.25 * (1. + (1.-0.8/2.)*0.5),
+		},
+		{
+			name:    "distant collision, use grid correction",
+			history: []*events.Object{objectOnLeftAt(0.8, 5000), objectOnLeftAt(0.8, 4900), objectOnLeftAt(0.8, 4800)},
+			object:  objectOnLeftAt(0.8, 4800),
+			want:    0.25,
+		},
+		{
+			name:    "close object at constant distance, use grid correction",
+			history: []*events.Object{objectOnLeftAt(0.8, 400), objectOnLeftAt(0.8, 400), objectOnLeftAt(0.8, 400)},
+			object:  objectOnLeftAt(0.8, 400),
+			want:    0.25,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTTCCorrector(
+				NewGridCorrector(WithDistanceSource(BottomDistance{})),
+				WithTTCTracker(NewTracker(WithMinHits(1), WithSmoothing(1.))),
+				WithTTCHorizon(2*time.Second),
+				WithTTCMaxGain(1.5),
+			)
+			for i, o := range tt.history {
+				c.OnObjects([]*events.Object{o}, start.Add(time.Duration(i)*100*time.Millisecond))
+			}
+			if got := c.AdjustFromObjectPosition(0., []*events.Object{tt.object}); math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("AdjustFromObjectPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTCCorrector_AdjustFromObjectPosition_StoppedObject(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewTTCCorrector(
+		NewGridCorrector(WithDistanceSource(BottomDistance{})),
+		WithTTCTracker(NewTracker(WithMinHits(1), WithSmoothing(0.5))),
+		WithTTCHorizon(2*time.Second),
+		WithTTCMaxGain(1.5),
+	)
+	// Object approaches, then stops: smoothed velocity decays toward 0 and time to collision grows without limit
+	tops := []float32{0.8, 0.78, 0.76}
+	for i := 0; i < 60; i++ {
+		top := tops[min(i, len(tops)-1)]
+		o := &events.Object{Left: 0.1, Top: top, Right: 0.3, Bottom: 0.9, Confidence: 0.9}
+		c.OnObjects([]*events.Object{o}, start.Add(time.Duration(i)*100*time.Millisecond))
+
+		got := c.AdjustFromObjectPosition(0., []*events.Object{o})
+		if i > 30 && math.Abs(got-0.25) > 1e-4 {
+			t.Fatalf("frame %d: AdjustFromObjectPosition() = %v, want grid correction 0.25 for a stopped object", i, got)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package steering
+
+import (
+	"fmt"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"io"
+	"math"
+	"strings"
+)
+
+// TuneSample is a steering decision of a human driver in front of an object
+type TuneSample struct {
+	// Baseline is the steering of the driver without objects
+	Baseline float64
+	// Steering is the steering of the driver in front of object
+	Steering float64
+	// Object is the nearest object
+	Object *events.Object
+}
+
+// Deviation returns correction applied by driver to avoid object
+func (s TuneSample) Deviation() float64 {
+	return s.Steering - s.Baseline
+}
+
+/*
+NewTuneSamples extracts samples from radio command steering of user drive mode records.
+
+Baseline of a sample is the steering of the last record without objects, or straight if none. Only the nearest object
+of each record is kept.
+*/
+func NewTuneSamples(records []*Record) []TuneSample {
+	var samples []TuneSample
+	baseline := 0.
+	for _, r := range records {
+		if r.DriveMode != events.DriveMode_USER || r.Steering == nil {
+			continue
+		}
+		steering := float64(r.Steering.GetSteering())
+		objects := r.Objects.GetObjects()
+		if len(objects) == 0 {
+			baseline = steering
+			continue
+		}
+		samples = append(samples, TuneSample{
+			Baseline: baseline,
+			Steering: steering,
+			Object:   nearestOnImage(objects),
+		})
+	}
+	return samples
+}
+
+// nearestOnImage returns object with the lowest bottom in image, first one if several, grid maps are fitted on bottom
+func nearestOnImage(objects []*events.Object) *events.Object {
+	nearest := objects[0]
+	for _, o := range objects[1:] {
+		if o.Bottom > nearest.Bottom {
+			nearest = o
+		}
+	}
+	return nearest
+}
+
+type OptionTuner func(t *Tuner)
+
+// WithTuneRegularization defines weight of initial value of cells, as a count of samples
+func WithTuneRegularization(lambda float64) OptionTuner {
+	return func(t *Tuner) {
+		t.lambda = lambda
+	}
+}
+
+// WithTuneDeltaMiddle defines half zone of baseline steering interpreted as straight, like WidthDeltaMiddle
+func WithTuneDeltaMiddle(delta float64) OptionTuner {
+	return func(t *Tuner) {
+		t.deltaMiddle = delta
+	}
+}
+
+// WithTuneFactorStep defines step of values tried for each objects move factor
+func WithTuneFactorStep(step float64) OptionTuner {
+	return func(t *Tuner) {
+		t.factorStep = step
+	}
+}
+
+/*
+NewTuner returns a tuner that starts from gridMap and objectMoveFactors, default grid maps are used if nil.
+
+Grid maps with distance steps in millimeters are rejected: samples are fitted on normalized bottom position of objects.
+*/
+func NewTuner(gridMap, objectMoveFactors *GridMap, options ...OptionTuner) (*Tuner, error) {
+	if gridMap == nil {
+		gridMap = &defaultGridMap
+	}
+	if objectMoveFactors == nil {
+		objectMoveFactors = &defaultObjectFactors
+	}
+	if err := checkTunable("grid map", gridMap); err != nil {
+		return nil, err
+	}
+	if err := checkTunable("objects move factors", objectMoveFactors); err != nil {
+		return nil, err
+	}
+	t := &Tuner{
+		initialGridMap:           gridMap,
+		initialObjectMoveFactors: objectMoveFactors,
+		gridMap:                  cloneGridMap(gridMap).WithInterpolation(InterpolationNearest),
+		objectMoveFactors:        cloneGridMap(objectMoveFactors).WithInterpolation(InterpolationNearest),
+		lambda:                   1.,
+		deltaMiddle:              0.1,
+		factorStep:               0.05,
+	}
+	for _, o := range options {
+		o(t)
+	}
+	return t, nil
+}
+
+// checkTunable returns an error if distance steps of grid map aren't normalized like bottom position of objects
+func checkTunable(name string, gm *GridMap) error {
+	if gm.DistanceUnit != "" && gm.DistanceUnit != DistanceNormalized {
+		return fmt.Errorf("%v: unsupported distance unit '%v', only normalized distance steps can be fitted", name, gm.DistanceUnit)
+	}
+	return nil
+}
+
+/*
+Tuner fits grid maps of GridCorrector to steering of a human driver avoiding objects.
+
+Samples with straight baseline fit GridMap data: each cell gets the least squares value of deviations of its samples,
+regularized toward its initial value. Samples with turning baseline then fit objects move factors: for each cell, a
+grid search keeps the factor that minimizes squared error of deviations computed by GridCorrector, with the same
+regularization. Factors are searched around their initial value, on a range as wide as [-1, 1] or initial factors if
+wider.
+
+Grid maps are fitted on bottom position of objects with nearest interpolation, whatever interpolation of initial grid
+maps: each sample concerns only one cell. Fitted grid maps are defined with nearest interpolation.
+*/
+type Tuner struct {
+	initialGridMap           *GridMap
+	initialObjectMoveFactors *GridMap
+	gridMap                  *GridMap
+	objectMoveFactors        *GridMap
+	lambda                   float64
+	deltaMiddle              float64
+	factorStep               float64
+}
+
+// GridMap returns fitted grid map
+func (t *Tuner) GridMap() *GridMap {
+	return t.gridMap
+}
+
+// ObjectMoveFactors returns fitted objects move factors
+func (t *Tuner) ObjectMoveFactors() *GridMap {
+	return t.objectMoveFactors
+}
+
+// TuneReport describes fit quality
+type TuneReport struct {
+	Samples         int
+	StraightSamples int
+	TurnSamples     int
+	// RMSEBefore and RMSEAfter are root mean square errors of deviations with initial and fitted grid maps
+	RMSEBefore float64
+	RMSEAfter  float64
+	// R2 is the coefficient of determination of deviations with fitted grid maps
+	R2 float64
+	// GridMapCounts and ObjectMoveFactorsCounts are count of samples by cell
+	GridMapCounts           [][]int
+	ObjectMoveFactorsCounts [][]int
+}
+
+// Fit fits grid maps to samples and returns fit quality
+func (t *Tuner) Fit(samples []TuneSample) *TuneReport {
+	report := &TuneReport{
+		Samples:                 len(samples),
+		GridMapCounts:           newCounts(t.gridMap),
+		ObjectMoveFactorsCounts: newCounts(t.objectMoveFactors),
+	}
+	report.RMSEBefore = t.rmse(samples, t.initialGridMap, t.initialObjectMoveFactors)
+
+	var straight, turn []TuneSample
+	for _, s := range samples {
+		if math.Abs(s.Baseline) < t.deltaMiddle {
+			straight = append(straight, s)
+		} else {
+			turn = append(turn, s)
+		}
+	}
+	report.StraightSamples, report.TurnSamples = len(straight), len(turn)
+
+	t.fitGridMap(straight, report.GridMapCounts)
+	t.fitObjectMoveFactors(turn, report.ObjectMoveFactorsCounts)
+
+	report.RMSEAfter = t.rmse(samples, t.gridMap, t.objectMoveFactors)
+	report.R2 = t.r2(samples)
+	return report
+}
+
+// fitGridMap sets each cell to regularized mean of deviations of its samples
+func (t *Tuner) fitGridMap(samples []TuneSample, counts [][]int) {
+	sums := make([][]float64, len(t.gridMap.Data))
+	for i, row := range t.gridMap.Data {
+		sums[i] = make([]float64, len(row))
+	}
+	corrector := t.corrector(t.initialGridMap, t.initialObjectMoveFactors)
+	for _, s := range samples {
+		_, diagnosis := corrector.Diagnose(s.Baseline, []*events.Object{s.Object})
+		if diagnosis.Cell == nil {
+			continue
+		}
+		counts[diagnosis.Cell.Row][diagnosis.Cell.Col]++
+		sums[diagnosis.Cell.Row][diagnosis.Cell.Col] += s.Deviation()
+	}
+	for i, row := range t.gridMap.Data {
+		for j := range row {
+			n := float64(counts[i][j])
+			if n == 0 {
+				continue
+			}
+			t.gridMap.Data[i][j] = (sums[i][j] + t.lambda*t.initialGridMap.Data[i][j]) / (n + t.lambda)
+		}
+	}
+}
+
+// fitObjectMoveFactors searches for each cell the factor with the lowest regularized squared error
+func (t *Tuner) fitObjectMoveFactors(samples []TuneSample, counts [][]int) {
+	byCell := make(map[GridCell][]TuneSample)
+	for _, s := range samples {
+		cell := t.objectMoveFactors.CellOf(float64(s.Object.Right), float64(s.Object.Bottom))
+		counts[cell.Row][cell.Col]++
+		byCell[cell] = append(byCell[cell], s)
+	}
+
+	steps := int(math.Round(factorRange(t.initialObjectMoveFactors) / t.factorStep))
+	for cell, cellSamples := range byCell {
+		initial := t.initialObjectMoveFactors.Data[cell.Row][cell.Col]
+		best, bestCost := initial, math.Inf(1)
+		for i := -steps; i <= steps; i++ {
+			factor := initial + float64(i)*t.factorStep
+			t.objectMoveFactors.Data[cell.Row][cell.Col] = factor
+			cost := t.sse(cellSamples, t.gridMap, t.objectMoveFactors) + t.lambda*(factor-initial)*(factor-initial)
+			if cost < bestCost {
+				best, bestCost = factor, cost
+			}
+		}
+		t.objectMoveFactors.Data[cell.Row][cell.Col] = best
+	}
+}
+
+// factorRange returns the half width of search range of objects move factors: 1, or the largest initial factor if larger
+func factorRange(gm *GridMap) float64 {
+	r := 1.
+	for _, row := range gm.Data {
+		for _, v := range row {
+			r = math.Max(r, math.Abs(v))
+		}
+	}
+	return r
+}
+
+// corrector returns a GridCorrector that uses grid maps with nearest interpolation
+func (t *Tuner) corrector(gm, omf *GridMap) *GridCorrector {
+	c := NewGridCorrector(WithDistanceSource(BottomDistance{}), WidthDeltaMiddle(t.deltaMiddle))
+	c.gridMap, c.objectMoveFactors = gm.WithInterpolation(InterpolationNearest), omf.WithInterpolation(InterpolationNearest)
+	return c
+}
+
+// sse returns sum of squared errors between deviations of samples and deviations computed with grid maps
+func (t *Tuner) sse(samples []TuneSample, gm, omf *GridMap) float64 {
+	corrector := t.corrector(gm, omf)
+	result := 0.
+	for _, s := range samples {
+		_, diagnosis := corrector.Diagnose(s.Baseline, []*events.Object{s.Object})
+		e := diagnosis.Deviation - s.Deviation()
+		result += e * e
+	}
+	return result
+}
+
+func (t *Tuner) rmse(samples []TuneSample, gm, omf *GridMap) float64 {
+	if len(samples) == 0 {
+		return 0.
+	}
+	return math.Sqrt(t.sse(samples, gm, omf) / float64(len(samples)))
+}
+
+// r2 returns coefficient of determination of fitted grid maps, 0 if deviations of samples don't vary
+func (t *Tuner) r2(samples []TuneSample) float64 {
+	deviations := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		deviations = append(deviations, s.Deviation())
+	}
+	m := mean(deviations)
+	sst := 0.
+	for _, d := range deviations {
+		sst += (d - m) * (d - m)
+	}
+	if sst == 0. {
+		return 0.
+	}
+	return 1. - t.sse(samples, t.gridMap, t.objectMoveFactors)/sst
+}
+
+// WriteTo writes a readable fit quality report
+func (r *TuneReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "samples             : %d (%d straight, %d turn)\n", r.Samples, r.StraightSamples, r.TurnSamples)
+	fmt.Fprintf(&b, "rmse before/after   : %.4f/%.4f\n", r.RMSEBefore, r.RMSEAfter)
+	fmt.Fprintf(&b, "r2                  : %.4f\n", r.R2)
+	writeCounts(&b, "grid map samples by cell", r.GridMapCounts)
+	writeCounts(&b, "objects move factors samples by cell", r.ObjectMoveFactorsCounts)
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeCounts(b *strings.Builder, title string, counts [][]int) {
+	fmt.Fprintf(b, "%s:\n", title)
+	for _, row := range counts {
+		for _, c := range row {
+			fmt.Fprintf(b, " %5d", c)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func newCounts(gm *GridMap) [][]int {
+	counts := make([][]int, len(gm.Data))
+	for i, row := range gm.Data {
+		counts[i] = make([]int, len(row))
+	}
+	return counts
+}
+
+func cloneGridMap(gm *GridMap) *GridMap {
+	clone := *gm
+	clone.Data = make([][]float64, len(gm.Data))
+	for i, row := range gm.Data {
+		clone.Data[i] = append([]float64(nil), row...)
+	}
+	return &clone
+}
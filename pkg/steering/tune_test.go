@@ -0,0 +1,199 @@
+package steering
+
+import (
+	"bytes"
+	"github.com/cyrilix/robocar-protobuf/go/events"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewTuneSamples(t *testing.T) {
+	objects := &events.ObjectsMessage{Objects: []*events.Object{&objectOnMiddleDistant, &objectOnMiddleNear}}
+	records := []*Record{
+		{DriveMode: events.DriveMode_USER, Steering: &events.SteeringMessage{Steering: 0.3}, Objects: objects},
+		{DriveMode: events.DriveMode_USER, Steering: &events.SteeringMessage{Steering: 0.2}},
+		{DriveMode: events.DriveMode_PILOT, Steering: &events.SteeringMessage{Steering: 0.9}, Objects: objects},
+		{DriveMode: events.DriveMode_USER, Steering: &events.SteeringMessage{Steering: 0.7}, Objects: objects},
+		{DriveMode: events.DriveMode_USER, Objects: objects},
+	}
+
+	samples := NewTuneSamples(records)
+
+	tests := []struct {
+		baseline float64
+		steering float64
+	}{
+		{baseline: 0., steering: 0.3},
+		{baseline: 0.2, steering: 0.7},
+	}
+	if len(samples) != len(tests) {
+		t.Fatalf("bad samples count: %v, wants %v", len(samples), len(tests))
+	}
+	for i, tt := range tests {
+		s := samples[i]
+		if math.Abs(s.Baseline-tt.baseline) > 1e-6 || math.Abs(s.Steering-tt.steering) > 1e-6 {
+			t.Errorf("sample %d: bad baseline/steering: %v/%v, wants %v/%v", i, s.Baseline, s.Steering, tt.baseline, tt.steering)
+		}
+		if s.Object != &objectOnMiddleNear {
+			t.Errorf("sample %d: nearest object is expected, got %v", i, s.Object)
+		}
+	}
+}
+
+func TestTuner_Fit_GridMap(t *testing.T) {
+	samples := []TuneSample{
+		{Baseline: 0., Steering: 0.5, Object: &objectOnMiddleNear},
+		{Baseline: 0.05, Steering: 0.65, Object: &objectOnMiddleNear},
+		{Baseline: -0.05, Steering: 0.65, Object: &objectOnMiddleNear},
+	}
+	tests := []struct {
+		name     string
+		lambda   float64
+		wantCell float64
+	}{
+		{name: "least squares", lambda: 0., wantCell: 0.6},
+		{name: "regularized toward initial value", lambda: 1., wantCell: (1.8 + 1.) / 4.},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tuner := newTestTuner(t, nil, nil, WithTuneRegularization(tt.lambda))
+			report := tuner.Fit(samples)
+
+			if got := tuner.GridMap().Data[4][2]; math.Abs(got-tt.wantCell) > 1e-6 {
+				t.Errorf("bad fitted cell: %v, wants %v", got, tt.wantCell)
+			}
+			if defaultGridMap.Data[4][2] != 1. {
+				t.Errorf("default grid map must not be modified: %v", defaultGridMap.Data[4][2])
+			}
+			if report.StraightSamples != 3 || report.TurnSamples != 0 {
+				t.Errorf("bad straight/turn samples: %v/%v, wants 3/0", report.StraightSamples, report.TurnSamples)
+			}
+			if report.GridMapCounts[4][2] != 3 {
+				t.Errorf("bad samples count of cell: %v, wants 3", report.GridMapCounts[4][2])
+			}
+			// Errors of deviations are 0.5, 0.4 and 0.3 with default grid map
+			if want := math.Sqrt(0.5 / 3.); math.Abs(report.RMSEBefore-want) > 1e-6 {
+				t.Errorf("bad rmse before fit: %v, wants %v", report.RMSEBefore, want)
+			}
+			if report.RMSEAfter >= report.RMSEBefore {
+				t.Errorf("rmse after fit %v must be lower than before %v", report.RMSEAfter, report.RMSEBefore)
+			}
+		})
+	}
+}
+
+func TestTuner_Fit_ObjectMoveFactors(t *testing.T) {
+	// Driver turns left and steers right to avoid object, as if object hasn't moved
+	samples := []TuneSample{
+		{Baseline: -0.9, Steering: 0.1, Object: &objectOnMiddleNear},
+	}
+	tuner := newTestTuner(t, nil, nil, WithTuneRegularization(0.01))
+	report := tuner.Fit(samples)
+
+	if report.TurnSamples != 1 {
+		t.Errorf("bad turn samples: %v, wants 1", report.TurnSamples)
+	}
+	if report.ObjectMoveFactorsCounts[4][4] != 1 {
+		t.Errorf("bad samples count of cell: %v, wants 1", report.ObjectMoveFactorsCounts[4][4])
+	}
+	if got := tuner.ObjectMoveFactors().Data[4][4]; got == defaultObjectFactors.Data[4][4] {
+		t.Errorf("objects move factor hasn't been fitted: %v", got)
+	}
+	if report.RMSEAfter > 1e-6 {
+		t.Errorf("bad rmse after fit: %v, wants 0", report.RMSEAfter)
+	}
+	if report.RMSEBefore <= report.RMSEAfter {
+		t.Errorf("rmse before fit %v must be greater than after %v", report.RMSEBefore, report.RMSEAfter)
+	}
+}
+
+func TestTuner_Fit_ObjectMoveFactors_OutOfDefaultRange(t *testing.T) {
+	initial := cloneGridMap(&defaultObjectFactors)
+	initial.Data[4][4] = 2.5
+	// Driver deviation matches initial factor, out of [-1, 1]
+	sample := TuneSample{Baseline: -0.9, Object: &objectOnMiddleNear}
+	tuner := newTestTuner(t, nil, initial, WithTuneRegularization(0.01))
+	_, diagnosis := tuner.corrector(tuner.GridMap(), initial).Diagnose(sample.Baseline, []*events.Object{sample.Object})
+	sample.Steering = sample.Baseline + diagnosis.Deviation
+
+	report := tuner.Fit([]TuneSample{sample})
+
+	if got := tuner.ObjectMoveFactors().Data[4][4]; math.Abs(got-2.5) > 1e-6 {
+		t.Errorf("bad fitted factor: %v, wants %v", got, 2.5)
+	}
+	if report.RMSEAfter > 1e-6 {
+		t.Errorf("bad rmse after fit: %v, wants 0", report.RMSEAfter)
+	}
+}
+
+func TestTuner_Interpolation(t *testing.T) {
+	bilinear := defaultGridMap.WithInterpolation(InterpolationBilinear)
+	tuner := newTestTuner(t, bilinear, bilinear)
+	tuner.Fit([]TuneSample{{Baseline: 0., Steering: 0.5, Object: &objectOnMiddleNear}})
+
+	for name, gm := range map[string]*GridMap{"grid map": tuner.GridMap(), "objects move factors": tuner.ObjectMoveFactors()} {
+		if gm.Interpolation != InterpolationNearest {
+			t.Errorf("bad interpolation of fitted %v: %v, wants %v", name, gm.Interpolation, InterpolationNearest)
+		}
+	}
+	if bilinear.Interpolation != InterpolationBilinear {
+		t.Errorf("initial grid map must not be modified: %v", bilinear.Interpolation)
+	}
+}
+
+func TestNewTuner_DistanceUnit(t *testing.T) {
+	mm := cloneGridMap(&defaultGridMap)
+	mm.DistanceUnit = DistanceMm
+	normalized := cloneGridMap(&defaultGridMap)
+	normalized.DistanceUnit = DistanceNormalized
+
+	tests := []struct {
+		name              string
+		gridMap           *GridMap
+		objectMoveFactors *GridMap
+		wantErr           string
+	}{
+		{name: "default grid maps"},
+		{name: "normalized grid map", gridMap: normalized},
+		{name: "grid map in mm", gridMap: mm, wantErr: "grid map: unsupported distance unit 'mm'"},
+		{name: "objects move factors in mm", objectMoveFactors: mm, wantErr: "objects move factors: unsupported distance unit 'mm'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTuner(tt.gridMap, tt.objectMoveFactors)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("NewTuner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTuner() error = %v, want contains '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTuneReport_WriteTo(t *testing.T) {
+	report := newTestTuner(t, nil, nil).Fit([]TuneSample{
+		{Baseline: 0., Steering: 0.5, Object: &objectOnMiddleNear},
+		{Baseline: 0., Steering: 0.7, Object: &objectOnMiddleNear},
+	})
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatalf("unable to write report: %v", err)
+	}
+	for _, want := range []string{"samples             : 2 (2 straight, 0 turn)", "rmse before/after", "r2", "grid map samples by cell"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("'%v' not found in report:\n%v", want, buf.String())
+		}
+	}
+}
+
+func newTestTuner(t *testing.T, gridMap, objectMoveFactors *GridMap, options ...OptionTuner) *Tuner {
+	t.Helper()
+	tuner, err := NewTuner(gridMap, objectMoveFactors, options...)
+	if err != nil {
+		t.Fatalf("unable to create tuner: %v", err)
+	}
+	return tuner
+}
@@ -0,0 +1,149 @@
+package steering
+
+import (
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+// SafeSteering defines steering published when input is missing
+type SafeSteering string
+
+const (
+	// SafeCentre publishes straight steering at once
+	SafeCentre SafeSteering = "centre"
+	// SafeDecay moves linearly last published steering to straight over decay duration
+	SafeDecay SafeSteering = "decay"
+)
+
+type OptionWatchdog func(w *Watchdog)
+
+// WithSafeSteering defines steering to publish on failsafe
+func WithSafeSteering(s SafeSteering) OptionWatchdog {
+	return func(w *Watchdog) {
+		w.safeSteering = s
+	}
+}
+
+// WithSafeDecay defines duration to move last steering to straight, for SafeDecay
+func WithSafeDecay(d time.Duration) OptionWatchdog {
+	return func(w *Watchdog) {
+		w.decay = d
+	}
+}
+
+func NewWatchdog(timeout time.Duration, options ...OptionWatchdog) *Watchdog {
+	w := &Watchdog{
+		timeout:      timeout,
+		safeSteering: SafeCentre,
+		decay:        500 * time.Millisecond,
+		lastInputs:   make(map[Source]time.Time),
+	}
+	for _, o := range options {
+		o(w)
+	}
+	return w
+}
+
+/*
+Watchdog detects missing steering input on active source.
+
+A fault is raised when no input is received on active source since timeout, delay starts from last input or from
+activation of source if more recent. Fault is cleared on next input of active source.
+*/
+type Watchdog struct {
+	timeout      time.Duration
+	safeSteering SafeSteering
+	decay        time.Duration
+
+	mu          sync.Mutex
+	lastInputs  map[Source]time.Time
+	armedAt     time.Time
+	lastValue   float64
+	fault       bool
+	faultSource Source
+	faultValue  float64
+	faultSince  time.Time
+}
+
+// Timeout returns delay without input before fault
+func (w *Watchdog) Timeout() time.Duration {
+	return w.timeout
+}
+
+// Arm starts timeout of a newly active source
+func (w *Watchdog) Arm(ts time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armedAt = ts
+}
+
+// Feed records input of source and clears fault of this source
+func (w *Watchdog) Feed(source Source, ts time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastInputs[source] = ts
+	if w.fault && w.faultSource == source {
+		zap.S().Infof("failsafe: %v steering received after %v, leave failsafe", source, ts.Sub(w.faultSince))
+		w.fault = false
+	}
+}
+
+// Published records last published steering, used as start value of SafeDecay
+func (w *Watchdog) Published(value float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.fault {
+		w.lastValue = value
+	}
+}
+
+// Fault returns true while active source is missing
+func (w *Watchdog) Fault() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.fault
+}
+
+/*
+Check verifies input of active source at ts. If input is missing, returns safe steering to publish, true, and true if
+fault has just been raised. Centre is the straight steering value, trim of car included.
+*/
+func (w *Watchdog) Check(active Source, centre float64, ts time.Time) (float64, bool, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.armedAt.IsZero() {
+		w.armedAt = ts
+	}
+	last := w.lastInputs[active]
+	if w.armedAt.After(last) {
+		last = w.armedAt
+	}
+	if ts.Sub(last) <= w.timeout {
+		if w.fault {
+			// Active source has changed since fault
+			zap.S().Infof("failsafe: %v steering is active, leave failsafe", active)
+			w.fault = false
+		}
+		return 0., false, false
+	}
+
+	raised := false
+	if !w.fault || w.faultSource != active {
+		w.fault, w.faultSource, w.faultValue, w.faultSince = true, active, w.lastValue, ts
+		raised = true
+	}
+	return w.safeValue(centre, ts), true, raised
+}
+
+// safeValue returns steering to publish at ts, lock must be held
+func (w *Watchdog) safeValue(centre float64, ts time.Time) float64 {
+	if w.safeSteering != SafeDecay || w.decay <= 0 {
+		return centre
+	}
+	remaining := 1. - float64(ts.Sub(w.faultSince))/float64(w.decay)
+	return centre + (w.faultValue-centre)*math.Max(remaining, 0.)
+}
@@ -0,0 +1,107 @@
+package steering
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		offset     time.Duration
+		feed       Source
+		published  *float64
+		check      Source
+		want       float64
+		wantFault  bool
+		wantRaised bool
+	}
+	value := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		options []OptionWatchdog
+		centre  float64
+		steps   []step
+	}{
+		{
+			name: "input received",
+			steps: []step{
+				{offset: 0, check: SourceTF},
+				{offset: 50 * time.Millisecond, feed: SourceTF, check: SourceTF},
+				{offset: 140 * time.Millisecond, check: SourceTF},
+			},
+		},
+		{
+			name: "missing input at start",
+			steps: []step{
+				{offset: 0, check: SourceTF},
+				{offset: 100 * time.Millisecond, check: SourceTF},
+				{offset: 101 * time.Millisecond, check: SourceTF, want: 0., wantFault: true, wantRaised: true},
+			},
+		},
+		{
+			name:   "centre with trim",
+			centre: 0.1,
+			steps: []step{
+				{offset: 0, feed: SourceTF, published: value(0.5), check: SourceTF},
+				{offset: 150 * time.Millisecond, check: SourceTF, want: 0.1, wantFault: true, wantRaised: true},
+				{offset: 200 * time.Millisecond, check: SourceTF, want: 0.1, wantFault: true, wantRaised: false},
+			},
+		},
+		{
+			name:    "decay to centre",
+			options: []OptionWatchdog{WithSafeSteering(SafeDecay), WithSafeDecay(200 * time.Millisecond)},
+			steps: []step{
+				{offset: 0, feed: SourceTF, published: value(0.8), check: SourceTF},
+				{offset: 150 * time.Millisecond, check: SourceTF, want: 0.8, wantFault: true, wantRaised: true},
+				{offset: 200 * time.Millisecond, check: SourceTF, want: 0.6, wantFault: true},
+				{offset: 300 * time.Millisecond, check: SourceTF, want: 0.2, wantFault: true},
+				{offset: 400 * time.Millisecond, check: SourceTF, want: 0., wantFault: true},
+			},
+		},
+		{
+			name: "input resumes",
+			steps: []step{
+				{offset: 0, feed: SourceTF, check: SourceTF},
+				{offset: 150 * time.Millisecond, check: SourceTF, want: 0., wantFault: true, wantRaised: true},
+				{offset: 160 * time.Millisecond, feed: SourceTF, check: SourceTF},
+			},
+		},
+		{
+			name: "other source doesn't feed active source",
+			steps: []step{
+				{offset: 0, feed: SourceTF, check: SourceTF},
+				{offset: 80 * time.Millisecond, feed: SourceRC, check: SourceTF},
+				{offset: 150 * time.Millisecond, feed: SourceRC, check: SourceTF, want: 0., wantFault: true, wantRaised: true},
+				// Active source changes
+				{offset: 160 * time.Millisecond, check: SourceRC},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWatchdog(100*time.Millisecond, tt.options...)
+			for i, st := range tt.steps {
+				ts := start.Add(st.offset)
+				if st.feed != "" {
+					w.Feed(st.feed, ts)
+				}
+				if st.published != nil {
+					w.Published(*st.published)
+				}
+				got, fault, raised := w.Check(st.check, tt.centre, ts)
+				if fault != st.wantFault || raised != st.wantRaised {
+					t.Errorf("step %d: Check() fault = %v, raised = %v, want %v, %v", i, fault, raised, st.wantFault, st.wantRaised)
+				}
+				if math.Abs(got-st.want) > 1e-9 {
+					t.Errorf("step %d: Check() = %v, want %v", i, got, st.want)
+				}
+				if w.Fault() != st.wantFault {
+					t.Errorf("step %d: Fault() = %v, want %v", i, w.Fault(), st.wantFault)
+				}
+			}
+		})
+	}
+}